@@ -1,44 +1,241 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/cfmobile/gopivnet/cache"
 	"github.com/cfmobile/gopivnet/resource"
 )
 
+// PivnetUrl is the Pivotal Network api used by New unless WithEndpoint says
+// otherwise.
+const PivnetUrl = "https://network.pivotal.io"
+
+// Api finds, downloads and publishes product files. Every method has a
+// *Context variant that stops when ctx is done; the others use
+// context.Background().
 type Api interface {
+	GetProducts() ([]resource.ProductSummary, error)
 	GetLatestProductFile(productName string, fileType string) (*resource.ProductFile, error)
 	GetProductFileForVersion(productName, version string, fileType string) (*resource.ProductFile, error)
 	GetVersionsForProduct(productName string) ([]string, error)
+	GetReleases(productName string, filter ReleaseFilter) ([]resource.Release, error)
+	ResolveVersion(productName, constraint string) (*resource.Release, error)
+	GetProductFilesForVersion(productName, version string) (*resource.ProductFiles, error)
+	FindProductFiles(productName, version string, selector FileSelector) ([]resource.ProductFile, error)
+	GetProductFile(productName, version string, selector FileSelector) (*resource.ProductFile, error)
+	GetEula(productName, version string) (*resource.Eula, error)
 	Download(productFile *resource.ProductFile, fileName string) error
+	DownloadWithOptions(productFile *resource.ProductFile, fileName string, options DownloadOptions) error
+	DownloadRelease(productName, version, destDir string) error
+	DownloadReleaseWithOptions(productName, version, destDir string, options DownloadOptions) error
+	CreateRelease(productName string, release resource.NewRelease, userGroupIds []int) (*resource.Release, error)
+	UploadProductFile(productName, version, fileName string, productFile resource.NewProductFile) (*resource.ProductFile, error)
+	SearchProducts(query string) ([]resource.ProductSummary, error)
+	ResolveDependencies(productName, version string) ([]Dependency, error)
+	DownloadDependencies(productName, version, destDir string, globs []string, options DownloadOptions) error
+	PlanUpgrade(productName, from, to string) ([]resource.Release, error)
+	VerifyFile(fileName string) (*Receipt, error)
+
+	GetProductsContext(ctx context.Context) ([]resource.ProductSummary, error)
+	GetLatestProductFileContext(ctx context.Context, productName string, fileType string) (*resource.ProductFile, error)
+	GetProductFileForVersionContext(ctx context.Context, productName, version string, fileType string) (*resource.ProductFile, error)
+	GetVersionsForProductContext(ctx context.Context, productName string) ([]string, error)
+	GetReleasesContext(ctx context.Context, productName string, filter ReleaseFilter) ([]resource.Release, error)
+	ResolveVersionContext(ctx context.Context, productName, constraint string) (*resource.Release, error)
+	GetProductFilesForVersionContext(ctx context.Context, productName, version string) (*resource.ProductFiles, error)
+	FindProductFilesContext(ctx context.Context, productName, version string, selector FileSelector) ([]resource.ProductFile, error)
+	GetProductFileContext(ctx context.Context, productName, version string, selector FileSelector) (*resource.ProductFile, error)
+	GetEulaContext(ctx context.Context, productName, version string) (*resource.Eula, error)
+	DownloadContext(ctx context.Context, productFile *resource.ProductFile, fileName string, options DownloadOptions) error
+	DownloadReleaseContext(ctx context.Context, productName, version, destDir string, options DownloadOptions) error
+	CreateReleaseContext(ctx context.Context, productName string, release resource.NewRelease, userGroupIds []int) (*resource.Release, error)
+	UploadProductFileContext(ctx context.Context, productName, version, fileName string, productFile resource.NewProductFile) (*resource.ProductFile, error)
+	SearchProductsContext(ctx context.Context, query string) ([]resource.ProductSummary, error)
+	ResolveDependenciesContext(ctx context.Context, productName, version string) ([]Dependency, error)
+	DownloadDependenciesContext(ctx context.Context, productName, version, destDir string, globs []string, options DownloadOptions) error
+	PlanUpgradeContext(ctx context.Context, productName, from, to string) ([]resource.Release, error)
+	VerifyFileContext(ctx context.Context, fileName string) (*Receipt, error)
 }
 
 type PivnetApi struct {
 	Requester resource.ReleaseRequester
+	// RetryPolicy applies to downloads from the url returned by the
+	// Requester. The zero value uses resource.DefaultRetryPolicy.
+	RetryPolicy resource.RetryPolicy
+	// ReleaseFilter is applied to the releases of every product before
+	// resolving versions, so "latest" means the latest matching release.
+	ReleaseFilter ReleaseFilter
+	// Cache, if set, serves downloads of files it already holds without
+	// contacting Pivotal Network, and keeps every file downloaded.
+	Cache *cache.Cache
+	// HttpClient downloads files from the url returned by the Requester. Nil
+	// uses http.DefaultClient.
+	HttpClient *http.Client
+}
+
+type config struct {
+	retryPolicy    resource.RetryPolicy
+	requestTimeout time.Duration
+	releaseFilter  ReleaseFilter
+	eulaPolicy     resource.EulaPolicy
+	eulaAuditLog   io.Writer
+	cacheDir       string
+	endpoint       string
+	authenticator  resource.Authenticator
+	transport
+}
+
+type Option func(*config)
+
+// WithRetryPolicy sets the retry policy of both the Pivotal Network api
+// requests and the downloads.
+func WithRetryPolicy(policy resource.RetryPolicy) Option {
+	return func(c *config) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithRequestTimeout limits how long a single Pivotal Network api request may
+// take. It doesn't apply to downloads, which can legitimately take hours; use
+// the *Context methods to bound those.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.requestTimeout = timeout
+	}
+}
+
+// WithReleaseFilter sets PivnetApi.ReleaseFilter.
+func WithReleaseFilter(filter ReleaseFilter) Option {
+	return func(c *config) {
+		c.releaseFilter = filter
+	}
+}
+
+// WithEulaPolicy sets the policy deciding which eulas are accepted when a
+// download requires one. Eulas are accepted automatically by default.
+func WithEulaPolicy(policy resource.EulaPolicy) Option {
+	return func(c *config) {
+		c.eulaPolicy = policy
+	}
 }
 
-func New(token string) Api {
+// WithEulaAuditLog records every eula acceptance to w, see
+// resource.EulaAcceptance.
+func WithEulaAuditLog(w io.Writer) Option {
+	return func(c *config) {
+		c.eulaAuditLog = w
+	}
+}
+
+// WithEndpoint talks to the api at url, e.g. an internal mirror of Pivotal
+// Network, instead of PivnetUrl.
+func WithEndpoint(url string) Option {
+	return func(c *config) {
+		if url != "" {
+			c.endpoint = strings.TrimSuffix(url, "/")
+		}
+	}
+}
+
+// WithAuthenticator authenticates with auth instead of the token passed to
+// New, e.g. resource.RefreshToken(PivnetUrl, token) for a refresh token that
+// New would mistake for a legacy one.
+func WithAuthenticator(auth resource.Authenticator) Option {
+	return func(c *config) {
+		c.authenticator = auth
+	}
+}
+
+// WithCache keeps downloaded files in dir and reuses them, see cache.Cache.
+func WithCache(dir string) Option {
+	return func(c *config) {
+		c.cacheDir = dir
+	}
+}
+
+// New returns an Api for Pivotal Network. token is either a legacy api token
+// or a UAA refresh token, which ends in "-r"; access tokens are fetched with a
+// refresh token and renewed when they expire. WithAuthenticator overrides the
+// guess.
+func New(token string, options ...Option) Api {
+	c := config{
+		retryPolicy: resource.DefaultRetryPolicy,
+		eulaPolicy:  resource.AutoAcceptEula,
+		endpoint:    PivnetUrl,
+	}
+	for _, option := range options {
+		option(&c)
+	}
+
+	var downloadCache *cache.Cache
+	if c.cacheDir != "" {
+		downloadCache = cache.New(c.cacheDir)
+	}
+
+	client := c.httpClient()
+
+	requesterOptions := []resource.ClientOption{
+		resource.WithHttpClient(client),
+		resource.WithRetryPolicy(c.retryPolicy),
+		resource.WithRequestTimeout(c.requestTimeout),
+		resource.WithEulaPolicy(c.eulaPolicy),
+		resource.WithEulaAuditLog(c.eulaAuditLog),
+	}
+	if c.authenticator != nil {
+		requesterOptions = append(requesterOptions, resource.WithAuthenticator(c.authenticator))
+	}
+
 	return &PivnetApi{
-		Requester: resource.NewRequester("https://network.pivotal.io", token),
+		Requester:     resource.NewRequester(c.endpoint, token, requesterOptions...),
+		RetryPolicy:   c.retryPolicy,
+		ReleaseFilter: c.releaseFilter,
+		Cache:         downloadCache,
+		HttpClient:    client,
+	}
+}
+
+// GetProducts returns every product listed on Pivotal Network.
+func (p *PivnetApi) GetProducts() ([]resource.ProductSummary, error) {
+	return p.GetProductsContext(context.Background())
+}
+
+func (p *PivnetApi) GetProductsContext(ctx context.Context) ([]resource.ProductSummary, error) {
+	products, err := p.Requester.GetProductsContext(ctx)
+	if err != nil {
+		return nil, err
 	}
+	return products.Products, nil
 }
 
 func (p *PivnetApi) GetLatestProductFile(productName string, fileType string) (*resource.ProductFile, error) {
+	return p.GetLatestProductFileContext(context.Background(), productName, fileType)
+}
+
+func (p *PivnetApi) GetLatestProductFileContext(ctx context.Context, productName string, fileType string) (*resource.ProductFile, error) {
 	if productName == "" {
 		return nil, errors.New("Must specify a product name")
 	}
 
-	prod, err := p.Requester.GetProduct(productName)
+	prod, err := p.getProduct(ctx, productName)
 	if err != nil {
 		return nil, err
 	}
 
-	productFiles, err := p.Requester.GetProductFiles(prod.Releases[0])
+	latest, err := latestRelease(prod)
+	if err != nil {
+		return nil, err
+	}
+
+	productFiles, err := p.Requester.GetProductFilesContext(ctx, *latest)
 	if err != nil {
 		return nil, err
 	}
@@ -62,6 +259,10 @@ func getPivotalProduct(productFiles *resource.ProductFiles, fileType string) *re
 }
 
 func (p *PivnetApi) GetProductFileForVersion(productName, version string, fileType string) (*resource.ProductFile, error) {
+	return p.GetProductFileForVersionContext(context.Background(), productName, version, fileType)
+}
+
+func (p *PivnetApi) GetProductFileForVersionContext(ctx context.Context, productName, version string, fileType string) (*resource.ProductFile, error) {
 	if productName == "" {
 		return nil, errors.New("Must specify a product name")
 	}
@@ -70,17 +271,17 @@ func (p *PivnetApi) GetProductFileForVersion(productName, version string, fileTy
 		return nil, errors.New("Must specify a product version")
 	}
 
-	prod, err := p.Requester.GetProduct(productName)
+	prod, err := p.getProduct(ctx, productName)
 	if err != nil {
 		return nil, err
 	}
 
-	matchingRelease := getReleaseForVersion(prod, version)
-	if matchingRelease == nil {
-		return nil, errors.New("Specified version not found")
+	matchingRelease, err := resolveRelease(prod, version)
+	if err != nil {
+		return nil, err
 	}
 
-	productFiles, err := p.Requester.GetProductFiles(*matchingRelease)
+	productFiles, err := p.Requester.GetProductFilesContext(ctx, *matchingRelease)
 	if err != nil {
 		return nil, err
 	}
@@ -93,23 +294,17 @@ func (p *PivnetApi) GetProductFileForVersion(productName, version string, fileTy
 	return pivotalProduct, nil
 }
 
-func getReleaseForVersion(product *resource.Product, version string) *resource.Release {
-	for index, release := range product.Releases {
-		if release.Version == version {
-			return &product.Releases[index]
-		}
-	}
-
-	return nil
+func (p *PivnetApi) GetVersionsForProduct(productName string) ([]string, error) {
+	return p.GetVersionsForProductContext(context.Background(), productName)
 }
 
-func (p *PivnetApi) GetVersionsForProduct(productName string) ([]string, error) {
+func (p *PivnetApi) GetVersionsForProductContext(ctx context.Context, productName string) ([]string, error) {
 
 	if len(productName) == 0 {
 		return []string{}, errors.New("Product name was empty")
 	}
 
-	product, err := p.Requester.GetProduct(productName)
+	product, err := p.getProduct(ctx, productName)
 
 	if err != nil {
 		return []string{}, err
@@ -122,34 +317,267 @@ func (p *PivnetApi) GetVersionsForProduct(productName string) ([]string, error)
 	return versions, nil
 }
 
+// GetReleases returns the releases of the product matching filter, on top of
+// PivnetApi.ReleaseFilter, in the order returned by Pivotal Network.
+func (p *PivnetApi) GetReleases(productName string, filter ReleaseFilter) ([]resource.Release, error) {
+	return p.GetReleasesContext(context.Background(), productName, filter)
+}
+
+func (p *PivnetApi) GetReleasesContext(ctx context.Context, productName string, filter ReleaseFilter) ([]resource.Release, error) {
+	if productName == "" {
+		return nil, errors.New("Must specify a product name")
+	}
+
+	product, err := p.getProduct(ctx, productName)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter.Apply(product.Releases), nil
+}
+
+// getProduct fetches the product and drops the releases not matching
+// p.ReleaseFilter.
+func (p *PivnetApi) getProduct(ctx context.Context, productName string) (*resource.Product, error) {
+	product, err := p.Requester.GetProductContext(ctx, productName)
+	if err != nil {
+		return nil, p.unknownProduct(ctx, productName, err)
+	}
+
+	if p.ReleaseFilter.IsZero() {
+		return product, nil
+	}
+
+	filtered := *product
+	filtered.Releases = p.ReleaseFilter.Apply(product.Releases)
+	return &filtered, nil
+}
+
+// ResolveVersion returns the release of the product matching constraint. An
+// exact version string is preferred; otherwise constraint is parsed as a
+// semver constraint (see semver.ParseConstraint) and the highest matching
+// release wins. An empty constraint or "latest" resolves to the latest release.
+func (p *PivnetApi) ResolveVersion(productName, constraint string) (*resource.Release, error) {
+	return p.ResolveVersionContext(context.Background(), productName, constraint)
+}
+
+func (p *PivnetApi) ResolveVersionContext(ctx context.Context, productName, constraint string) (*resource.Release, error) {
+	if productName == "" {
+		return nil, errors.New("Must specify a product name")
+	}
+
+	prod, err := p.getProduct(ctx, productName)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveRelease(prod, constraint)
+}
+
+// GetProductFilesForVersion returns every file of the release matching
+// version, which is resolved like in ResolveVersion.
+func (p *PivnetApi) GetProductFilesForVersion(productName, version string) (*resource.ProductFiles, error) {
+	return p.GetProductFilesForVersionContext(context.Background(), productName, version)
+}
+
+func (p *PivnetApi) GetProductFilesForVersionContext(ctx context.Context, productName, version string) (*resource.ProductFiles, error) {
+	release, err := p.ResolveVersionContext(ctx, productName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Requester.GetProductFilesContext(ctx, *release)
+}
+
+// FindProductFiles returns every file of the release matching version that
+// is picked by selector.
+func (p *PivnetApi) FindProductFiles(productName, version string, selector FileSelector) ([]resource.ProductFile, error) {
+	return p.FindProductFilesContext(context.Background(), productName, version, selector)
+}
+
+func (p *PivnetApi) FindProductFilesContext(ctx context.Context, productName, version string, selector FileSelector) ([]resource.ProductFile, error) {
+	productFiles, err := p.GetProductFilesForVersionContext(ctx, productName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return SelectFiles(productFiles, selector)
+}
+
+// GetProductFile returns the single file of the release matching version that
+// is picked by selector, or an *AmbiguousMatchError if several files are.
+func (p *PivnetApi) GetProductFile(productName, version string, selector FileSelector) (*resource.ProductFile, error) {
+	return p.GetProductFileContext(context.Background(), productName, version, selector)
+}
+
+func (p *PivnetApi) GetProductFileContext(ctx context.Context, productName, version string, selector FileSelector) (*resource.ProductFile, error) {
+	files, err := p.FindProductFilesContext(ctx, productName, version, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) > 1 {
+		return nil, &AmbiguousMatchError{Candidates: fileNames(files)}
+	}
+	return &files[0], nil
+}
+
+// GetEula returns the eula of the release matching version, including its
+// content.
+func (p *PivnetApi) GetEula(productName, version string) (*resource.Eula, error) {
+	return p.GetEulaContext(context.Background(), productName, version)
+}
+
+func (p *PivnetApi) GetEulaContext(ctx context.Context, productName, version string) (*resource.Eula, error) {
+	release, err := p.ResolveVersionContext(ctx, productName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if release.Eula.Slug == "" {
+		return nil, fmt.Errorf("Release %s of %s has no eula", release.Version, productName)
+	}
+
+	return p.Requester.GetEulaContext(ctx, release.Eula.Slug)
+}
+
 func (p *PivnetApi) Download(productFile *resource.ProductFile, fileName string) error {
+	return p.DownloadWithOptions(productFile, fileName, DownloadOptions{})
+}
+
+func (p *PivnetApi) DownloadWithOptions(productFile *resource.ProductFile, fileName string, options DownloadOptions) error {
+	return p.DownloadContext(context.Background(), productFile, fileName, options)
+}
+
+// DownloadContext downloads productFile to fileName. If ctx is cancelled the
+// partial file is removed rather than kept for resuming.
+func (p *PivnetApi) DownloadContext(ctx context.Context, productFile *resource.ProductFile, fileName string, options DownloadOptions) error {
 	if productFile == nil {
 		return errors.New("Nil product passed in")
 	}
 
-	url, err := p.Requester.GetProductDownloadUrl(productFile)
+	start := time.Now()
+	key, cacheable := p.cacheKey(productFile)
+	if cacheable {
+		found, err := p.Cache.Fetch(key, fileName)
+		if err != nil {
+			options.warn(fmt.Errorf("Unable to use the cached %s: %w", key, err))
+		}
+		if found {
+			return finishDownload(options, productFile, fileName, start, true)
+		}
+	}
+
+	url, err := p.Requester.GetProductDownloadUrlContext(ctx, productFile)
 	if err != nil {
 		return err
 	}
 
-	return download(url, fileName)
+	err = download(ctx, p.httpClient(), url, productFile, fileName, options, p.retryPolicy())
+	if err != nil {
+		return err
+	}
+
+	if cacheable {
+		err = p.Cache.Store(key, fileName)
+		if err != nil {
+			options.warn(fmt.Errorf("Unable to cache \"%s\": %w", fileName, err))
+		}
+	}
+	return finishDownload(options, productFile, fileName, start, false)
+}
+
+// finishDownload writes the receipt of a file and reports its result.
+func finishDownload(options DownloadOptions, productFile *resource.ProductFile, fileName string, start time.Time, cached bool) error {
+	if options.Receipt {
+		err := writeReceipt(options, productFile, fileName)
+		if err != nil {
+			return fmt.Errorf("Unable to write the receipt of \"%s\": %w", fileName, err)
+		}
+	}
+	if options.Done == nil {
+		return nil
+	}
+
+	result := DownloadResult{
+		ProductSlug: options.productSlug,
+		Release:     options.release,
+		ProductFile: productFile,
+		FileName:    fileName,
+		Duration:    time.Since(start),
+		Cached:      cached,
+	}
+	if info, err := os.Stat(fileName); err == nil {
+		result.Bytes = info.Size()
+	}
+	options.Done(result)
+	return nil
+}
+
+func (p *PivnetApi) cacheKey(productFile *resource.ProductFile) (cache.Key, bool) {
+	if p.Cache == nil {
+		return cache.Key{}, false
+	}
+	return cache.KeyFor(productFile)
+}
+
+func (p *PivnetApi) DownloadRelease(productName, version, destDir string) error {
+	return p.DownloadReleaseWithOptions(productName, version, destDir, DownloadOptions{})
 }
 
-func download(url, fileName string) error {
-	out, err := os.Create(fileName)
-	defer out.Close()
+func (p *PivnetApi) DownloadReleaseWithOptions(productName, version, destDir string, options DownloadOptions) error {
+	return p.DownloadReleaseContext(context.Background(), productName, version, destDir, options)
+}
 
-	resp, err := http.Get(url)
+// DownloadReleaseContext downloads every file of the release matching version
+// into destDir, creating it if needed. Files are named after
+// ProductFile.Name(); if two files share a name the later one is prefixed
+// with its id.
+func (p *PivnetApi) DownloadReleaseContext(ctx context.Context, productName, version, destDir string, options DownloadOptions) error {
+	release, err := p.ResolveVersionContext(ctx, productName, version)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	n, err := io.Copy(out, resp.Body)
+	productFiles, err := p.Requester.GetProductFilesContext(ctx, *release)
 	if err != nil {
 		return err
 	}
+	options = options.ForRelease(productName, *release)
+
+	err = os.MkdirAll(destDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for index := range productFiles.Files {
+		productFile := &productFiles.Files[index]
 
-	fmt.Printf("Wrote %d bytes to \"%s\"\n", n, fileName)
+		name := productFile.Name()
+		if seen[name] {
+			name = fmt.Sprintf("%d-%s", productFile.Id, name)
+		}
+		seen[name] = true
+
+		err = p.DownloadContext(ctx, productFile, filepath.Join(destDir, name), options)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+func (p *PivnetApi) httpClient() *http.Client {
+	if p.HttpClient == nil {
+		return http.DefaultClient
+	}
+	return p.HttpClient
+}
+
+func (p *PivnetApi) retryPolicy() resource.RetryPolicy {
+	if p.RetryPolicy.MaxAttempts == 0 {
+		return resource.DefaultRetryPolicy
+	}
+	return p.RetryPolicy
+}
@@ -1,12 +1,22 @@
 package api_test
 
 import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	pivnetapi "github.com/cfmobile/gopivnet/api"
+	"github.com/cfmobile/gopivnet/cache"
 	"github.com/cfmobile/gopivnet/resource"
 	"github.com/cfmobile/gopivnet/resource/fakes"
 
@@ -53,8 +63,8 @@ var _ = Describe("Api", func() {
 		}
 
 		requester = new(fakes.FakeReleaseRequester)
-		requester.GetProductReturns(prod, nil)
-		requester.GetProductFilesReturns(productFiles, nil)
+		requester.GetProductContextReturns(prod, nil)
+		requester.GetProductFilesContextReturns(productFiles, nil)
 
 		api = &pivnetapi.PivnetApi{
 			Requester: requester,
@@ -62,6 +72,95 @@ var _ = Describe("Api", func() {
 
 	})
 
+	Context("GetProducts", func() {
+		It("returns the products from the requester", func() {
+			products := []resource.ProductSummary{resource.ProductSummary{Id: 1, Slug: "p-redis"}}
+			requester.GetProductsContextReturns(&resource.Products{Products: products}, nil)
+
+			res, err := api.GetProducts()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal(products))
+		})
+
+		It("returns an error if the requester fails", func() {
+			requester.GetProductsContextReturns(nil, errors.New("err"))
+
+			_, err := api.GetProducts()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("SearchProducts", func() {
+		BeforeEach(func() {
+			requester.GetProductsContextReturns(&resource.Products{Products: []resource.ProductSummary{
+				resource.ProductSummary{Id: 1, Slug: "p-redis", Name: "Redis for PCF"},
+				resource.ProductSummary{Id: 2, Slug: "p-mysql", Name: "MySQL for PCF"},
+				resource.ProductSummary{Id: 3, Slug: "elastic-runtime", Name: "Pivotal Application Service"},
+				resource.ProductSummary{Id: 4, Slug: "redis-enterprise", Name: "Redis Enterprise"},
+			}}, nil)
+		})
+
+		slugs := func(products []resource.ProductSummary) []string {
+			result := []string{}
+			for _, product := range products {
+				result = append(result, product.Slug)
+			}
+			return result
+		}
+
+		It("returns every product for an empty query", func() {
+			products, err := api.SearchProducts("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(products).To(HaveLen(4))
+		})
+
+		It("ignores case and punctuation", func() {
+			products, err := api.SearchProducts("P_Redis")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(slugs(products)).To(Equal([]string{"p-redis"}))
+		})
+
+		It("ranks exact matches before prefixes and substrings", func() {
+			products, err := api.SearchProducts("redis")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(slugs(products)).To(Equal([]string{"p-redis", "redis-enterprise"}))
+		})
+
+		It("matches names", func() {
+			products, err := api.SearchProducts("application service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(slugs(products)).To(Equal([]string{"elastic-runtime"}))
+		})
+
+		It("tolerates typos", func() {
+			products, err := api.SearchProducts("elastic-runtme")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(slugs(products)).To(Equal([]string{"elastic-runtime"}))
+
+			products, err = api.SearchProducts("cassandra")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(products).To(BeEmpty())
+		})
+
+		It("suggests similar slugs when a product isn't found", func() {
+			requester.GetProductContextReturns(nil, &resource.APIError{StatusCode: http.StatusNotFound})
+
+			_, err := api.GetVersionsForProduct("p_redis")
+			Expect(errors.Is(err, resource.ErrNotFound)).To(BeTrue())
+			Expect(err).To(BeAssignableToTypeOf(&pivnetapi.UnknownProductError{}))
+			Expect(err.(*pivnetapi.UnknownProductError).Suggestions).To(Equal([]string{"p-redis"}))
+			Expect(err.Error()).To(Equal(`Product "p_redis" not found, did you mean "p-redis"?`))
+		})
+
+		It("doesn't suggest anything for other errors", func() {
+			requester.GetProductContextReturns(nil, errors.New("connection refused"))
+
+			_, err := api.GetVersionsForProduct("p_redis")
+			Expect(err).To(MatchError("connection refused"))
+			Expect(requester.GetProductsContextCallCount()).To(Equal(0))
+		})
+	})
+
 	Context("GetLatestProductFile", func() {
 		It("returns an error if there is no product name", func() {
 			res, err := api.GetLatestProductFile("", "pivotal")
@@ -73,12 +172,13 @@ var _ = Describe("Api", func() {
 		It("fetches the right product", func() {
 			api.GetLatestProductFile("myprod", "pivotal")
 
-			Expect(requester.GetProductCallCount()).To(Equal(1))
-			Expect(requester.GetProductArgsForCall(0)).To(Equal("myprod"))
+			Expect(requester.GetProductContextCallCount()).To(Equal(1))
+			_, productName := requester.GetProductContextArgsForCall(0)
+			Expect(productName).To(Equal("myprod"))
 		})
 
 		It("returns an error if fetching a product fails", func() {
-			requester.GetProductReturns(nil, errors.New("err"))
+			requester.GetProductContextReturns(nil, errors.New("err"))
 			res, err := api.GetLatestProductFile("myprod", "pivotal")
 
 			Expect(res).To(BeNil())
@@ -88,12 +188,13 @@ var _ = Describe("Api", func() {
 		It("fetches the product files from the returned product", func() {
 			api.GetLatestProductFile("myprod", "pivotal")
 
-			Expect(requester.GetProductFilesCallCount()).To(Equal(1))
-			Expect(requester.GetProductFilesArgsForCall(0)).To(Equal(prod.Releases[0]))
+			Expect(requester.GetProductFilesContextCallCount()).To(Equal(1))
+			_, release := requester.GetProductFilesContextArgsForCall(0)
+			Expect(release).To(Equal(prod.Releases[0]))
 		})
 
 		It("returns an error if GetProductFiles fails", func() {
-			requester.GetProductFilesReturns(nil, errors.New("err"))
+			requester.GetProductFilesContextReturns(nil, errors.New("err"))
 			res, err := api.GetLatestProductFile("myprod", "pivotal")
 
 			Expect(res).To(BeNil())
@@ -116,7 +217,7 @@ var _ = Describe("Api", func() {
 
 		It("returns an error if there's no pivotal product", func() {
 			productFiles.Files = productFiles.Files[:1]
-			requester.GetProductFilesReturns(productFiles, nil)
+			requester.GetProductFilesContextReturns(productFiles, nil)
 
 			res, err := api.GetLatestProductFile("myprod", "pivotal")
 
@@ -143,12 +244,13 @@ var _ = Describe("Api", func() {
 		It("fetches the right product", func() {
 			api.GetProductFileForVersion("myprod", "1.0", "pivotal")
 
-			Expect(requester.GetProductCallCount()).To(Equal(1))
-			Expect(requester.GetProductArgsForCall(0)).To(Equal("myprod"))
+			Expect(requester.GetProductContextCallCount()).To(Equal(1))
+			_, productName := requester.GetProductContextArgsForCall(0)
+			Expect(productName).To(Equal("myprod"))
 		})
 
 		It("returns an error if fetching a product fails", func() {
-			requester.GetProductReturns(nil, errors.New("err"))
+			requester.GetProductContextReturns(nil, errors.New("err"))
 			res, err := api.GetProductFileForVersion("myprod", "1.0", "pivotal")
 
 			Expect(res).To(BeNil())
@@ -158,12 +260,13 @@ var _ = Describe("Api", func() {
 		It("fetches the product files from the returned product", func() {
 			api.GetProductFileForVersion("myprod", "1.0", "pivotal")
 
-			Expect(requester.GetProductFilesCallCount()).To(Equal(1))
-			Expect(requester.GetProductFilesArgsForCall(0)).To(Equal(prod.Releases[1]))
+			Expect(requester.GetProductFilesContextCallCount()).To(Equal(1))
+			_, release := requester.GetProductFilesContextArgsForCall(0)
+			Expect(release).To(Equal(prod.Releases[1]))
 		})
 
 		It("returns an error if GetProductFiles fails", func() {
-			requester.GetProductFilesReturns(nil, errors.New("err"))
+			requester.GetProductFilesContextReturns(nil, errors.New("err"))
 			res, err := api.GetProductFileForVersion("myprod", "1.0", "pivotal")
 
 			Expect(res).To(BeNil())
@@ -186,7 +289,7 @@ var _ = Describe("Api", func() {
 
 		It("returns an error if there's no pivotal product", func() {
 			productFiles.Files = productFiles.Files[:1]
-			requester.GetProductFilesReturns(productFiles, nil)
+			requester.GetProductFilesContextReturns(productFiles, nil)
 
 			res, err := api.GetProductFileForVersion("myprod", "1.0", "pivotal")
 
@@ -195,6 +298,309 @@ var _ = Describe("Api", func() {
 		})
 	})
 
+	Context("ResolveVersion", func() {
+		BeforeEach(func() {
+			prod.Releases = []resource.Release{
+				resource.Release{Id: 5, Version: "1.9.0-beta.1"},
+				resource.Release{Id: 4, Version: "1.8.12"},
+				resource.Release{Id: 3, Version: "1.8.3"},
+				resource.Release{Id: 2, Version: "1.7.12"},
+				resource.Release{Id: 1, Version: "1.10"},
+			}
+		})
+
+		It("returns an error if there is no product name", func() {
+			res, err := api.ResolveVersion("", "1.8")
+
+			Expect(res).To(BeNil())
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns an error if fetching a product fails", func() {
+			requester.GetProductContextReturns(nil, errors.New("err"))
+			res, err := api.ResolveVersion("myprod", "1.8")
+
+			Expect(res).To(BeNil())
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns the highest release that isn't a pre-release for latest", func() {
+			res, err := api.ResolveVersion("myprod", "latest")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Id).To(Equal(1))
+
+			res, err = api.ResolveVersion("myprod", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Id).To(Equal(1))
+		})
+
+		It("returns the latest patch of a minor line", func() {
+			res, err := api.ResolveVersion("myprod", "1.8")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Id).To(Equal(4))
+
+			res, err = api.ResolveVersion("myprod", "1.8.*")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Id).To(Equal(4))
+		})
+
+		It("returns the highest release matching the constraint", func() {
+			res, err := api.ResolveVersion("myprod", ">=1.7.3 <1.8.12")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Id).To(Equal(3))
+
+			res, err = api.ResolveVersion("myprod", "~> 1.7")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Id).To(Equal(1))
+		})
+
+		It("prefers an exact version match", func() {
+			res, err := api.ResolveVersion("myprod", "1.9.0-beta.1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Id).To(Equal(5))
+		})
+
+		It("returns an error if no release matches", func() {
+			res, err := api.ResolveVersion("myprod", "~> 2.0")
+			Expect(res).To(BeNil())
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns an error if the constraint is invalid", func() {
+			res, err := api.ResolveVersion("myprod", ">= banana")
+			Expect(res).To(BeNil())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("GetProductFilesForVersion", func() {
+		It("returns all the files of the matching release", func() {
+			res, err := api.GetProductFilesForVersion("myprod", "1.0")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal(productFiles))
+			_, release := requester.GetProductFilesContextArgsForCall(0)
+			Expect(release).To(Equal(prod.Releases[1]))
+		})
+
+		It("returns an error if no release matches", func() {
+			res, err := api.GetProductFilesForVersion("myprod", "3.0")
+
+			Expect(res).To(BeNil())
+			Expect(err).To(HaveOccurred())
+			Expect(requester.GetProductFilesContextCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("FindProductFiles", func() {
+		BeforeEach(func() {
+			productFiles.Files = []resource.ProductFile{
+				resource.ProductFile{Id: 21, DisplayName: "Stemcell for vSphere", AwsObjectKey: "stemcells/bosh-stemcell-3263.8-vsphere-esxi-ubuntu-trusty-go_agent.tgz"},
+				resource.ProductFile{Id: 22, DisplayName: "Stemcell for AWS", AwsObjectKey: "stemcells/light-bosh-stemcell-3263.8-aws-xen-hvm-ubuntu-trusty-go_agent.tgz"},
+				resource.ProductFile{Id: 23, DisplayName: "Release Notes", AwsObjectKey: "stemcells/notes.pdf"},
+			}
+		})
+
+		It("selects files by glob", func() {
+			res, err := api.FindProductFiles("myprod", "1.0", pivnetapi.FileSelector{Glob: "*vsphere*"})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal(productFiles.Files[:1]))
+		})
+
+		It("selects files by regex", func() {
+			res, err := api.FindProductFiles("myprod", "1.0", pivnetapi.FileSelector{Regex: `(aws|vsphere).*\.tgz$`})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal(productFiles.Files[:2]))
+		})
+
+		It("selects files by name and id", func() {
+			res, err := api.FindProductFiles("myprod", "1.0", pivnetapi.FileSelector{Name: "Release Notes"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal(productFiles.Files[2:]))
+
+			res, err = api.FindProductFiles("myprod", "1.0", pivnetapi.FileSelector{Id: 22})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal(productFiles.Files[1:2]))
+		})
+
+		It("combines the criteria", func() {
+			res, err := api.FindProductFiles("myprod", "1.0", pivnetapi.FileSelector{FileType: "tgz", Glob: "light-*"})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal(productFiles.Files[1:2]))
+		})
+
+		It("lists the available files if nothing matches", func() {
+			res, err := api.FindProductFiles("myprod", "1.0", pivnetapi.FileSelector{Glob: "*azure*"})
+
+			Expect(res).To(BeNil())
+			Expect(err).To(BeAssignableToTypeOf(&pivnetapi.NoMatchError{}))
+			Expect(err.(*pivnetapi.NoMatchError).Available).To(ContainElement("notes.pdf"))
+		})
+
+		It("returns an error if the regex is invalid", func() {
+			_, err := api.FindProductFiles("myprod", "1.0", pivnetapi.FileSelector{Regex: "("})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("GetProductFile", func() {
+		It("returns the only matching file", func() {
+			res, err := api.GetProductFile("myprod", "1.0", pivnetapi.FileSelector{Glob: "*.pivotal"})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal(&productFiles.Files[1]))
+		})
+
+		It("lists the candidates if more than one file matches", func() {
+			res, err := api.GetProductFile("myprod", "1.0", pivnetapi.FileSelector{Glob: "*o*"})
+
+			Expect(res).To(BeNil())
+			Expect(err).To(BeAssignableToTypeOf(&pivnetapi.AmbiguousMatchError{}))
+			Expect(err.(*pivnetapi.AmbiguousMatchError).Candidates).To(Equal([]string{"product.pivotal", "cool.zip"}))
+		})
+	})
+
+	Context("GetEula", func() {
+		It("returns the eula of the matching release", func() {
+			prod.Releases[1].Eula = resource.Eula{Slug: "pivotal-eula"}
+			requester.GetEulaContextReturns(&resource.Eula{Slug: "pivotal-eula", Content: "terms"}, nil)
+
+			eula, err := api.GetEula("myprod", "1.0")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(eula.Content).To(Equal("terms"))
+
+			_, slug := requester.GetEulaContextArgsForCall(0)
+			Expect(slug).To(Equal("pivotal-eula"))
+		})
+
+		It("returns an error if the release has no eula", func() {
+			_, err := api.GetEula("myprod", "1.0")
+			Expect(err).To(HaveOccurred())
+			Expect(requester.GetEulaContextCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("CreateRelease", func() {
+		BeforeEach(func() {
+			requester.CreateReleaseContextReturns(&resource.Release{Id: 3, Version: "3.0", Availability: "Admins Only"}, nil)
+		})
+
+		It("returns an error if there is no version", func() {
+			_, err := api.CreateRelease("myprod", resource.NewRelease{}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(requester.CreateReleaseContextCallCount()).To(Equal(0))
+		})
+
+		It("creates the release", func() {
+			release, err := api.CreateRelease("myprod", resource.NewRelease{Version: "3.0"}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.Id).To(Equal(3))
+
+			_, slug, newRelease := requester.CreateReleaseContextArgsForCall(0)
+			Expect(slug).To(Equal("myprod"))
+			Expect(newRelease.Version).To(Equal("3.0"))
+			Expect(requester.UpdateReleaseAvailabilityContextCallCount()).To(Equal(0))
+		})
+
+		It("restricts the release to the user groups", func() {
+			release, err := api.CreateRelease("myprod", resource.NewRelease{Version: "3.0"}, []int{4, 9})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.Availability).To(Equal("Selected User Groups Only"))
+
+			_, slug, releaseId, availability, userGroupIds := requester.UpdateReleaseAvailabilityContextArgsForCall(0)
+			Expect(slug).To(Equal("myprod"))
+			Expect(releaseId).To(Equal(3))
+			Expect(availability).To(Equal("Selected User Groups Only"))
+			Expect(userGroupIds).To(Equal([]int{4, 9}))
+		})
+	})
+
+	Context("UploadProductFile", func() {
+		var fileName string
+		var uploaded string
+
+		BeforeEach(func() {
+			dir, err := ioutil.TempDir("", "gopivnet-upload")
+			Expect(err).ToNot(HaveOccurred())
+			fileName = filepath.Join(dir, "product.pivotal")
+			Expect(ioutil.WriteFile(fileName, []byte("tile"), 0644)).To(Succeed())
+
+			requester.UploadFileContextStub = func(_ context.Context, _, _ string, content io.Reader) error {
+				data, err := ioutil.ReadAll(content)
+				uploaded = string(data)
+				return err
+			}
+			requester.CreateProductFileContextReturns(&resource.ProductFile{Id: 77}, nil)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(filepath.Dir(fileName))
+		})
+
+		It("uploads the file, creates it and adds it to the release", func() {
+			productFile, err := api.UploadProductFile("myprod", "1.0", fileName, resource.NewProductFile{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(productFile.Id).To(Equal(77))
+			Expect(uploaded).To(Equal("tile"))
+
+			_, slug, objectKey, _ := requester.UploadFileContextArgsForCall(0)
+			Expect(slug).To(Equal("myprod"))
+			Expect(objectKey).To(Equal("product-files/myprod/product.pivotal"))
+
+			_, _, newFile := requester.CreateProductFileContextArgsForCall(0)
+			Expect(newFile).To(Equal(resource.NewProductFile{
+				Name:         "product.pivotal",
+				AwsObjectKey: "product-files/myprod/product.pivotal",
+				FileVersion:  "1.0",
+				FileType:     "Software",
+				Sha256:       "8b668b8994aa845107399994593d0ca831520be5257f005351a0ec13e97a39be",
+				Md5:          "13181d8cc01e390bf64c9e4b0d7a79f3",
+			}))
+
+			_, _, releaseId, productFileId := requester.AddProductFileContextArgsForCall(0)
+			Expect(releaseId).To(Equal(1))
+			Expect(productFileId).To(Equal(77))
+		})
+
+		It("keeps the fields that are set", func() {
+			_, err := api.UploadProductFile("myprod", "1.0", fileName, resource.NewProductFile{
+				Name:        "My Tile",
+				FileVersion: "1.0.1",
+				FileType:    "Documentation",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, newFile := requester.CreateProductFileContextArgsForCall(0)
+			Expect(newFile.Name).To(Equal("My Tile"))
+			Expect(newFile.FileVersion).To(Equal("1.0.1"))
+			Expect(newFile.FileType).To(Equal("Documentation"))
+		})
+
+		It("doesn't create the product file if the upload fails", func() {
+			requester.UploadFileContextStub = nil
+			requester.UploadFileContextReturns(errors.New("upload failed"))
+
+			_, err := api.UploadProductFile("myprod", "1.0", fileName, resource.NewProductFile{})
+			Expect(err).To(MatchError("upload failed"))
+			Expect(requester.CreateProductFileContextCallCount()).To(Equal(0))
+		})
+
+		It("requires the exact version of the release", func() {
+			_, err := api.UploadProductFile("myprod", "1", fileName, resource.NewProductFile{})
+			Expect(err).To(MatchError("No release 1 of myprod"))
+			Expect(requester.UploadFileContextCallCount()).To(Equal(0))
+		})
+
+		It("returns an error if the release doesn't exist", func() {
+			_, err := api.UploadProductFile("myprod", "9.9", fileName, resource.NewProductFile{})
+			Expect(err).To(HaveOccurred())
+			Expect(requester.UploadFileContextCallCount()).To(Equal(0))
+		})
+	})
+
 	Context("Download", func() {
 		var file *os.File
 		var server *ghttp.Server
@@ -212,6 +618,7 @@ var _ = Describe("Api", func() {
 
 		AfterEach(func() {
 			os.Remove(file.Name())
+			os.Remove(file.Name() + pivnetapi.PartialSuffix)
 			server.Close()
 		})
 
@@ -227,14 +634,14 @@ var _ = Describe("Api", func() {
 		})
 
 		It("returns an error if it can't get the product download url", func() {
-			requester.GetProductDownloadUrlReturns("", errors.New("err"))
+			requester.GetProductDownloadUrlContextReturns("", errors.New("err"))
 			err := api.Download(nil, file.Name())
 			Expect(err).To(HaveOccurred())
 			testFileIsEmpty()
 		})
 
 		It("downloads the data at the url", func() {
-			requester.GetProductDownloadUrlReturns(server.URL(), nil)
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
 			err := api.Download(&resource.ProductFile{}, file.Name())
 
 			Expect(err).ToNot(HaveOccurred())
@@ -242,36 +649,1147 @@ var _ = Describe("Api", func() {
 			Expect(res).To(Equal([]byte("aaa")))
 		})
 
-		It("returns an error if it can't write to the file", func() {
-			requester.GetProductDownloadUrlReturns(server.URL(), nil)
-			err := file.Chmod(0444)
+		It("reports the result of the download to Done", func() {
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			productFile := &resource.ProductFile{Id: 22, AwsObjectKey: "product.pivotal"}
+			results := []pivnetapi.DownloadResult{}
+			options := pivnetapi.DownloadOptions{
+				Done: func(result pivnetapi.DownloadResult) {
+					results = append(results, result)
+				},
+			}
+
+			err := api.DownloadWithOptions(productFile, file.Name(), options.ForRelease("myprod", prod.Releases[1]))
 
 			Expect(err).ToNot(HaveOccurred())
-			err = api.Download(&resource.ProductFile{}, file.Name())
-			Expect(err).To(HaveOccurred())
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].ProductSlug).To(Equal("myprod"))
+			Expect(results[0].Release).To(Equal(&prod.Releases[1]))
+			Expect(results[0].ProductFile).To(Equal(productFile))
+			Expect(results[0].FileName).To(Equal(file.Name()))
+			Expect(results[0].Bytes).To(Equal(int64(3)))
+			Expect(results[0].Cached).To(BeFalse())
 		})
-	})
 
-	Context("GetVersionsForProduct", func() {
-		It("Returns an error if the product is empty", func() {
-			versions, err := api.GetVersionsForProduct("")
-			Expect(err).To(HaveOccurred())
-			Expect(versions).To(BeEmpty())
+		It("resumes the download after the connection drops", func() {
+			api.RetryPolicy = resource.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+			server.SetHandler(0, func(w http.ResponseWriter, r *http.Request) {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				Expect(err).ToNot(HaveOccurred())
+				conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 6\r\n\r\naaa"))
+				conn.Close()
+			})
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("Range", "bytes=3-"),
+					ghttp.RespondWith(http.StatusPartialContent, `bbb`),
+				),
+			)
+
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			err := api.Download(&resource.ProductFile{}, file.Name())
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+			res, err := ioutil.ReadFile(file.Name())
+			Expect(res).To(Equal([]byte("aaabbb")))
 		})
 
-		It("returns an error if it can't get the product from the server", func() {
-			requester.GetProductReturns(nil, errors.New("err"))
+		It("keeps a complete partial file when the server has nothing left to send", func() {
+			Expect(ioutil.WriteFile(file.Name()+pivnetapi.PartialSuffix, []byte("aaa"), 0644)).To(Succeed())
+			server.SetHandler(0, ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("Range", "bytes=3-"),
+				ghttp.RespondWith(http.StatusRequestedRangeNotSatisfiable, "", http.Header{"Content-Range": []string{"bytes */3"}}),
+			))
+
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			err := api.Download(&resource.ProductFile{
+				Sha256: "9834876dcfb05cb167a5c24953eba58c4ac89b1adf57f28f2f9d09af107ee8f0",
+			}, file.Name())
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+			res, err := ioutil.ReadFile(file.Name())
+			Expect(res).To(Equal([]byte("aaa")))
+		})
+
+		It("starts over when a partial file of the right size can't be verified", func() {
+			Expect(ioutil.WriteFile(file.Name()+pivnetapi.PartialSuffix, []byte("a\x00a"), 0644)).To(Succeed())
+			server.SetHandler(0, ghttp.RespondWith(http.StatusRequestedRangeNotSatisfiable, "", http.Header{"Content-Range": []string{"bytes */3"}}))
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyHeader(http.Header{"Range": nil}),
+				ghttp.RespondWith(http.StatusOK, `aaa`),
+			))
+
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			err := api.Download(&resource.ProductFile{}, file.Name())
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+			res, err := ioutil.ReadFile(file.Name())
+			Expect(res).To(Equal([]byte("aaa")))
+		})
+
+		It("starts over when the partial file is larger than the object", func() {
+			Expect(ioutil.WriteFile(file.Name()+pivnetapi.PartialSuffix, []byte("stale data"), 0644)).To(Succeed())
+			server.SetHandler(0, ghttp.RespondWith(http.StatusRequestedRangeNotSatisfiable, "", http.Header{"Content-Range": []string{"bytes */3"}}))
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyHeader(http.Header{"Range": nil}),
+				ghttp.RespondWith(http.StatusOK, `aaa`),
+			))
+
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			err := api.Download(&resource.ProductFile{}, file.Name())
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+			res, err := ioutil.ReadFile(file.Name())
+			Expect(res).To(Equal([]byte("aaa")))
+		})
+
+		It("retries when the server is unavailable", func() {
+			api.RetryPolicy = resource.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Hour}
+			server.SetHandler(0, ghttp.RespondWith(http.StatusServiceUnavailable, "", http.Header{"Retry-After": []string{"0"}}))
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `aaa`))
+
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			err := api.Download(&resource.ProductFile{}, file.Name())
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+			res, err := ioutil.ReadFile(file.Name())
+			Expect(res).To(Equal([]byte("aaa")))
+		})
+
+		It("gives up after the policy's number of attempts", func() {
+			api.RetryPolicy = resource.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+			server.SetHandler(0, ghttp.RespondWith(http.StatusBadGateway, ""))
+			server.AppendHandlers(ghttp.RespondWith(http.StatusBadGateway, ""))
+
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			err := api.Download(&resource.ProductFile{}, file.Name())
 
-			versions, err := api.GetVersionsForProduct("NonexistentProduct")
 			Expect(err).To(HaveOccurred())
-			Expect(versions).To(BeEmpty())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
 		})
 
-		It("returns the list of versions for that product", func() {
-			versions, err := api.GetVersionsForProduct("ExistingProduct")
+		It("resumes a partial file left by a previous download", func() {
+			err := ioutil.WriteFile(file.Name()+pivnetapi.PartialSuffix, []byte("aa"), 0644)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(versions).To(ContainElement("1.0"))
-			Expect(versions).To(ContainElement("2.0"))
+
+			server.SetHandler(0, ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("Range", "bytes=2-"),
+				ghttp.RespondWith(http.StatusPartialContent, `a`),
+			))
+
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			err = api.Download(&resource.ProductFile{}, file.Name())
+
+			Expect(err).ToNot(HaveOccurred())
+			res, err := ioutil.ReadFile(file.Name())
+			Expect(res).To(Equal([]byte("aaa")))
+			_, err = os.Stat(file.Name() + pivnetapi.PartialSuffix)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("verifies the sha256 of the downloaded file", func() {
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			err := api.Download(&resource.ProductFile{
+				Sha256: "9834876dcfb05cb167a5c24953eba58c4ac89b1adf57f28f2f9d09af107ee8f0",
+			}, file.Name())
+
+			Expect(err).ToNot(HaveOccurred())
+			res, err := ioutil.ReadFile(file.Name())
+			Expect(res).To(Equal([]byte("aaa")))
+		})
+
+		It("returns an error and keeps the target untouched if the checksum does not match", func() {
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			err := api.Download(&resource.ProductFile{
+				Md5: "00000000000000000000000000000000",
+			}, file.Name())
+
+			Expect(err).To(BeAssignableToTypeOf(&pivnetapi.ChecksumError{}))
+			testFileIsEmpty()
+			_, err = os.Stat(file.Name() + pivnetapi.PartialSuffix)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("doesn't create the target if the download fails", func() {
+			fileName := file.Name() + "-new"
+			defer os.Remove(fileName + pivnetapi.PartialSuffix)
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+
+			err := api.Download(&resource.ProductFile{
+				Sha256: "0000000000000000000000000000000000000000000000000000000000000000",
+			}, fileName)
+
+			var checksumErr *pivnetapi.ChecksumError
+			Expect(errors.As(err, &checksumErr)).To(BeTrue())
+			Expect(checksumErr.FileName).To(Equal(fileName))
+			Expect(fileName).ToNot(BeAnExistingFile())
+		})
+
+		It("downloads the file in parallel chunks", func() {
+			server.RouteToHandler("GET", "/", func(w http.ResponseWriter, r *http.Request) {
+				http.ServeContent(w, r, "", time.Time{}, strings.NewReader("aaabbbccc"))
+			})
+
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			err := api.DownloadWithOptions(&resource.ProductFile{}, file.Name(), pivnetapi.DownloadOptions{
+				Parallel: 3,
+			})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(4))
+			ranges := []string{}
+			for _, r := range server.ReceivedRequests() {
+				ranges = append(ranges, r.Header.Get("Range"))
+			}
+			Expect(ranges).To(ConsistOf("bytes=0-0", "bytes=0-2", "bytes=3-5", "bytes=6-8"))
+			res, err := ioutil.ReadFile(file.Name())
+			Expect(res).To(Equal([]byte("aaabbbccc")))
+		})
+
+		It("retries the range probe of a parallel download", func() {
+			api.RetryPolicy = resource.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+			probes := 0
+			server.RouteToHandler("GET", "/", func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Range") == "bytes=0-0" {
+					probes++
+					if probes == 1 {
+						w.WriteHeader(http.StatusServiceUnavailable)
+						return
+					}
+				}
+				http.ServeContent(w, r, "", time.Time{}, strings.NewReader("aaabbbccc"))
+			})
+
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			err := api.DownloadWithOptions(&resource.ProductFile{}, file.Name(), pivnetapi.DownloadOptions{
+				Parallel: 3,
+			})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(probes).To(Equal(2))
+			res, err := ioutil.ReadFile(file.Name())
+			Expect(res).To(Equal([]byte("aaabbbccc")))
+		})
+
+		It("removes the partial file if a chunk fails", func() {
+			server.RouteToHandler("GET", "/", func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Range") == "bytes=0-2" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				http.ServeContent(w, r, "", time.Time{}, strings.NewReader("aaabbbccc"))
+			})
+
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			err := api.DownloadWithOptions(&resource.ProductFile{}, file.Name(), pivnetapi.DownloadOptions{
+				Parallel: 3,
+			})
+
+			Expect(err).To(HaveOccurred())
+			testFileIsEmpty()
+			Expect(file.Name() + pivnetapi.PartialSuffix).ToNot(BeAnExistingFile())
+		})
+
+		It("falls back to a single connection if the server doesn't support ranges", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusOK, `aaa`),
+			)
+
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			err := api.DownloadWithOptions(&resource.ProductFile{}, file.Name(), pivnetapi.DownloadOptions{
+				Parallel: 3,
+			})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+			res, err := ioutil.ReadFile(file.Name())
+			Expect(res).To(Equal([]byte("aaa")))
+		})
+
+		It("downloads an empty file over a single connection", func() {
+			server.RouteToHandler("GET", "/", func(w http.ResponseWriter, r *http.Request) {
+				http.ServeContent(w, r, "", time.Time{}, strings.NewReader(""))
+			})
+			Expect(ioutil.WriteFile(file.Name(), []byte("old"), 0644)).To(Succeed())
+
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			err := api.DownloadWithOptions(&resource.ProductFile{}, file.Name(), pivnetapi.DownloadOptions{
+				Parallel: 3,
+			})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+			Expect(server.ReceivedRequests()[0].Header.Get("Range")).To(Equal("bytes=0-0"))
+			testFileIsEmpty()
+		})
+
+		It("stops and removes the partial file when the context is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			server.SetHandler(0, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "6")
+				w.Write([]byte("aaa"))
+				w.(http.Flusher).Flush()
+				cancel()
+				<-r.Context().Done()
+			})
+
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			err := api.DownloadContext(ctx, &resource.ProductFile{}, file.Name(), pivnetapi.DownloadOptions{})
+
+			Expect(err).To(Equal(context.Canceled))
+			testFileIsEmpty()
+			_, err = os.Stat(file.Name() + pivnetapi.PartialSuffix)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("passes the context to the requester", func() {
+			type key struct{}
+			ctx := context.WithValue(context.Background(), key{}, "value")
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+
+			err := api.DownloadContext(ctx, &resource.ProductFile{}, file.Name(), pivnetapi.DownloadOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			requestCtx, _ := requester.GetProductDownloadUrlContextArgsForCall(0)
+			Expect(requestCtx).To(Equal(ctx))
+		})
+
+		It("returns an error if it can't write to the file", func() {
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			err := file.Chmod(0444)
+
+			Expect(err).ToNot(HaveOccurred())
+			err = api.Download(&resource.ProductFile{}, file.Name())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Download progress", func() {
+		var dir string
+		var server *ghttp.Server
+		var updates []pivnetapi.Progress
+		var lock sync.Mutex
+		var options pivnetapi.DownloadOptions
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			server = ghttp.NewServer()
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+
+			updates = nil
+			options = pivnetapi.DownloadOptions{
+				ProgressInterval: time.Millisecond,
+				Progress: pivnetapi.ProgressFunc(func(progress pivnetapi.Progress) {
+					lock.Lock()
+					defer lock.Unlock()
+					updates = append(updates, progress)
+				}),
+			}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+			server.Close()
+		})
+
+		lastUpdate := func() pivnetapi.Progress {
+			lock.Lock()
+			defer lock.Unlock()
+			Expect(updates).ToNot(BeEmpty())
+			return updates[len(updates)-1]
+		}
+
+		It("reports the bytes done and the total size, ending with a complete update", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `abcdef`))
+
+			fileName := filepath.Join(dir, "file")
+			err := api.DownloadWithOptions(&resource.ProductFile{}, fileName, options)
+			Expect(err).ToNot(HaveOccurred())
+
+			last := lastUpdate()
+			Expect(last.Complete).To(BeTrue())
+			Expect(last.Err).ToNot(HaveOccurred())
+			Expect(last.FileName).To(Equal(fileName))
+			Expect(last.Done).To(Equal(int64(6)))
+			Expect(last.Total).To(Equal(int64(6)))
+		})
+
+		It("counts the resumed part of a partial file", func() {
+			fileName := filepath.Join(dir, "file")
+			Expect(ioutil.WriteFile(fileName+pivnetapi.PartialSuffix, []byte("abc"), 0644)).To(Succeed())
+			server.AppendHandlers(ghttp.RespondWith(http.StatusPartialContent, `def`))
+
+			err := api.DownloadWithOptions(&resource.ProductFile{}, fileName, options)
+			Expect(err).ToNot(HaveOccurred())
+
+			last := lastUpdate()
+			Expect(last.Done).To(Equal(int64(6)))
+			Expect(last.Total).To(Equal(int64(6)))
+		})
+
+		It("reports parallel downloads", func() {
+			server.RouteToHandler("GET", "/", func(w http.ResponseWriter, r *http.Request) {
+				http.ServeContent(w, r, "file", time.Time{}, strings.NewReader("abcdefghij"))
+			})
+			options.Parallel = 3
+
+			err := api.DownloadWithOptions(&resource.ProductFile{}, filepath.Join(dir, "file"), options)
+			Expect(err).ToNot(HaveOccurred())
+
+			last := lastUpdate()
+			Expect(last.Done).To(Equal(int64(10)))
+			Expect(last.Total).To(Equal(int64(10)))
+		})
+
+		It("reports the error of a failed download", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusForbidden, ""))
+
+			err := api.DownloadWithOptions(&resource.ProductFile{}, filepath.Join(dir, "file"), options)
+			Expect(err).To(HaveOccurred())
+
+			last := lastUpdate()
+			Expect(last.Complete).To(BeTrue())
+			Expect(last.Err).To(HaveOccurred())
+		})
+
+		It("estimates the time left from the rate", func() {
+			progress := pivnetapi.Progress{Done: 25, Total: 125, Rate: 10}
+			Expect(progress.ETA()).To(Equal(10 * time.Second))
+
+			progress.Total = 0
+			Expect(progress.ETA()).To(BeZero())
+		})
+	})
+
+	Context("WithEndpoint", func() {
+		It("sends api requests to the endpoint", func() {
+			server := ghttp.NewServer()
+			defer server.Close()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/products"),
+					ghttp.RespondWith(http.StatusOK, `{"products":[{"id":1,"slug":"p-redis"}]}`),
+				),
+			)
+
+			products, err := pivnetapi.New("token", pivnetapi.WithEndpoint(server.URL()+"/")).GetProducts()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(products).To(HaveLen(1))
+		})
+	})
+
+	Context("transport options", func() {
+		var dir string
+		var server *ghttp.Server
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+			server.Close()
+		})
+
+		newApi := func(options ...pivnetapi.Option) *pivnetapi.PivnetApi {
+			api := pivnetapi.New("token", options...).(*pivnetapi.PivnetApi)
+			api.Requester = requester
+			return api
+		}
+
+		It("downloads through the proxy", func() {
+			server = ghttp.NewServer()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/bucket/product.pivotal"),
+					func(w http.ResponseWriter, r *http.Request) {
+						Expect(r.Host).To(Equal("downloads.example.com"))
+					},
+					ghttp.RespondWith(http.StatusOK, `aaa`),
+				),
+			)
+			proxy, _ := url.Parse(server.URL())
+			requester.GetProductDownloadUrlContextReturns("http://downloads.example.com/bucket/product.pivotal", nil)
+
+			err := newApi(pivnetapi.WithProxy(proxy)).Download(&resource.ProductFile{}, filepath.Join(dir, "product.pivotal"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		Context("with a private CA", func() {
+			var caBundle string
+
+			BeforeEach(func() {
+				server = ghttp.NewTLSServer()
+				server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `aaa`))
+				requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+
+				caBundle = filepath.Join(dir, "ca.pem")
+				certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.HTTPTestServer.Certificate().Raw})
+				Expect(ioutil.WriteFile(caBundle, certificate, 0644)).To(Succeed())
+			})
+
+			It("doesn't trust the server by default", func() {
+				err := newApi().Download(&resource.ProductFile{}, filepath.Join(dir, "file"))
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("trusts the server with the CA bundle", func() {
+				pool, err := pivnetapi.LoadCABundle(caBundle)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = newApi(pivnetapi.WithRootCAs(pool)).Download(&resource.ProductFile{}, filepath.Join(dir, "file"))
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("accepts the server when skipping verification", func() {
+				err := newApi(pivnetapi.WithInsecureSkipVerify(true)).Download(&resource.ProductFile{}, filepath.Join(dir, "file"))
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("prefers the http client over the other options", func() {
+				err := newApi(
+					pivnetapi.WithHttpClient(server.HTTPTestServer.Client()),
+					pivnetapi.WithProxy(&url.URL{Scheme: "http", Host: "127.0.0.1:1"}),
+				).Download(&resource.ProductFile{}, filepath.Join(dir, "file"))
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		It("returns an error if the CA bundle has no certificates", func() {
+			server = ghttp.NewServer()
+			caBundle := filepath.Join(dir, "ca.pem")
+			Expect(ioutil.WriteFile(caBundle, []byte("not a certificate"), 0644)).To(Succeed())
+
+			_, err := pivnetapi.LoadCABundle(caBundle)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Download with a cache", func() {
+		var dir string
+		var server *ghttp.Server
+		var productFile *resource.ProductFile
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			server = ghttp.NewServer()
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `aaa`))
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+
+			api.Cache = cache.New(filepath.Join(dir, "cache"))
+			productFile = &resource.ProductFile{
+				Id:     200,
+				Sha256: "9834876dcfb05cb167a5c24953eba58c4ac89b1adf57f28f2f9d09af107ee8f0",
+				Links: resource.Links{
+					"download": resource.Link{Url: "https://network.pivotal.io/api/v2/products/p-redis/releases/123/product_files/200/download"},
+				},
+			}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+			server.Close()
+		})
+
+		It("serves later downloads of the same file from the cache", func() {
+			err := api.Download(productFile, filepath.Join(dir, "first"))
+			Expect(err).ToNot(HaveOccurred())
+
+			err = api.Download(productFile, filepath.Join(dir, "second"))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(requester.GetProductDownloadUrlContextCallCount()).To(Equal(1))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+			res, err := ioutil.ReadFile(filepath.Join(dir, "second"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal([]byte("aaa")))
+		})
+
+		It("reports files served from the cache as cached", func() {
+			results := []pivnetapi.DownloadResult{}
+			options := pivnetapi.DownloadOptions{
+				Done: func(result pivnetapi.DownloadResult) {
+					results = append(results, result)
+				},
+			}
+
+			err := api.DownloadWithOptions(productFile, filepath.Join(dir, "first"), options)
+			Expect(err).ToNot(HaveOccurred())
+			err = api.DownloadWithOptions(productFile, filepath.Join(dir, "second"), options)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(results).To(HaveLen(2))
+			Expect(results[0].Cached).To(BeFalse())
+			Expect(results[1].Cached).To(BeTrue())
+			Expect(results[1].Bytes).To(Equal(int64(3)))
+		})
+
+		It("reports cache failures to Warn and downloads anyway", func() {
+			Expect(ioutil.WriteFile(filepath.Join(dir, "cache"), []byte("not a directory"), 0644)).To(Succeed())
+			warnings := []error{}
+			options := pivnetapi.DownloadOptions{
+				Warn: func(err error) {
+					warnings = append(warnings, err)
+				},
+			}
+
+			err := api.DownloadWithOptions(productFile, filepath.Join(dir, "first"), options)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(warnings).ToNot(BeEmpty())
+			Expect(warnings[len(warnings)-1].Error()).To(HavePrefix("Unable to cache"))
+			res, err := ioutil.ReadFile(filepath.Join(dir, "first"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(Equal([]byte("aaa")))
+		})
+
+		It("doesn't cache a file without a recognizable download link", func() {
+			productFile.Links = nil
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+
+			err := api.Download(productFile, filepath.Join(dir, "first"))
+			Expect(err).ToNot(HaveOccurred())
+
+			entries, err := api.Cache.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+	})
+
+	Context("Receipts", func() {
+		const sha = "9834876dcfb05cb167a5c24953eba58c4ac89b1adf57f28f2f9d09af107ee8f0"
+		var dir, fileName string
+		var server *ghttp.Server
+		var productFile *resource.ProductFile
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "")
+			Expect(err).ToNot(HaveOccurred())
+			fileName = filepath.Join(dir, "product.pivotal")
+
+			server = ghttp.NewServer()
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `aaa`))
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+
+			productFile = &resource.ProductFile{
+				Id:           200,
+				AwsObjectKey: "product-files/p-redis/product.pivotal",
+				FileVersion:  "1.0",
+				Sha256:       sha,
+				Links: resource.Links{
+					"download": resource.Link{Url: "https://network.pivotal.io/api/v2/products/p-redis/releases/123/product_files/200/download"},
+				},
+			}
+			requester.GetProductFileContextReturns(productFile, nil)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+			server.Close()
+		})
+
+		It("writes a receipt next to the downloaded file", func() {
+			options := pivnetapi.DownloadOptions{Receipt: true}
+			err := api.DownloadWithOptions(productFile, fileName, options.ForRelease("p-redis", resource.Release{Id: 123, Version: "1.0.0"}))
+			Expect(err).ToNot(HaveOccurred())
+
+			receipt, err := pivnetapi.ReadReceipt(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(receipt.Product).To(Equal("p-redis"))
+			Expect(receipt.ReleaseId).To(Equal(123))
+			Expect(receipt.ReleaseVersion).To(Equal("1.0.0"))
+			Expect(receipt.ProductFileId).To(Equal(200))
+			Expect(receipt.FileVersion).To(Equal("1.0"))
+			Expect(receipt.AwsObjectKey).To(Equal("product-files/p-redis/product.pivotal"))
+			Expect(receipt.Size).To(Equal(int64(3)))
+			Expect(receipt.Sha256).To(Equal(sha))
+			Expect(receipt.DownloadedAt).ToNot(BeZero())
+		})
+
+		It("takes the product and release from the download link and computes the sha256", func() {
+			productFile.Sha256 = ""
+			err := api.DownloadWithOptions(productFile, fileName, pivnetapi.DownloadOptions{Receipt: true})
+			Expect(err).ToNot(HaveOccurred())
+
+			receipt, err := pivnetapi.ReadReceipt(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(receipt.Product).To(Equal("p-redis"))
+			Expect(receipt.ReleaseId).To(Equal(123))
+			Expect(receipt.Sha256).To(Equal(sha))
+		})
+
+		It("doesn't write a receipt unless asked to", func() {
+			err := api.Download(productFile, fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pivnetapi.ReceiptName(fileName)).ToNot(BeAnExistingFile())
+		})
+
+		Context("VerifyFile", func() {
+			BeforeEach(func() {
+				err := api.DownloadWithOptions(productFile, fileName, pivnetapi.DownloadOptions{Receipt: true})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("checks the file against its receipt and Pivotal Network", func() {
+				receipt, err := api.VerifyFile(fileName)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(receipt.ProductFileId).To(Equal(200))
+				_, slug, releaseId, productFileId := requester.GetProductFileContextArgsForCall(0)
+				Expect(slug).To(Equal("p-redis"))
+				Expect(releaseId).To(Equal(123))
+				Expect(productFileId).To(Equal(200))
+			})
+
+			It("returns an error if the file changed", func() {
+				Expect(ioutil.WriteFile(fileName, []byte("bbb"), 0644)).To(Succeed())
+
+				_, err := api.VerifyFile(fileName)
+
+				var checksumErr *pivnetapi.ChecksumError
+				Expect(errors.As(err, &checksumErr)).To(BeTrue())
+				Expect(requester.GetProductFileContextCallCount()).To(Equal(0))
+			})
+
+			It("returns an error if the file was replaced on Pivotal Network", func() {
+				requester.GetProductFileContextReturns(&resource.ProductFile{
+					Id:           200,
+					AwsObjectKey: "product-files/p-redis/product-v2.pivotal",
+					FileVersion:  "1.0",
+					Sha256:       sha,
+				}, nil)
+
+				_, err := api.VerifyFile(fileName)
+
+				var mismatchErr *pivnetapi.ReceiptMismatchError
+				Expect(errors.As(err, &mismatchErr)).To(BeTrue())
+				Expect(mismatchErr.Field).To(Equal("aws_object_key"))
+			})
+
+			It("returns an error if the checksum changed on Pivotal Network", func() {
+				requester.GetProductFileContextReturns(&resource.ProductFile{Sha256: "abc"}, nil)
+
+				_, err := api.VerifyFile(fileName)
+
+				var checksumErr *pivnetapi.ChecksumError
+				Expect(errors.As(err, &checksumErr)).To(BeTrue())
+			})
+
+			It("doesn't ask Pivotal Network about a receipt without a product", func() {
+				receipt, err := pivnetapi.ReadReceipt(fileName)
+				Expect(err).ToNot(HaveOccurred())
+				receipt.Product = ""
+				content, err := json.Marshal(receipt)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ioutil.WriteFile(pivnetapi.ReceiptName(fileName), content, 0644)).To(Succeed())
+
+				_, err = api.VerifyFile(fileName)
+				Expect(err).To(MatchError(ContainSubstring("can only be checked offline")))
+				Expect(requester.GetProductFileContextCallCount()).To(Equal(0))
+			})
+
+			It("returns an error without a receipt", func() {
+				Expect(os.Remove(pivnetapi.ReceiptName(fileName))).To(Succeed())
+
+				_, err := api.VerifyFile(fileName)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Context("DownloadRelease", func() {
+		var dir string
+		var server *ghttp.Server
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			server = ghttp.NewServer()
+			server.RouteToHandler("GET", "/", ghttp.RespondWith(http.StatusOK, `aaa`))
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+			server.Close()
+		})
+
+		It("downloads every file of the release into the directory", func() {
+			err := api.DownloadRelease("myprod", "1.0", filepath.Join(dir, "release"))
+
+			Expect(err).ToNot(HaveOccurred())
+			_, release := requester.GetProductFilesContextArgsForCall(0)
+			Expect(release).To(Equal(prod.Releases[1]))
+			Expect(requester.GetProductDownloadUrlContextCallCount()).To(Equal(3))
+			Expect(filepath.Join(dir, "release", "readme")).To(BeAnExistingFile())
+			Expect(filepath.Join(dir, "release", "product.pivotal")).To(BeAnExistingFile())
+			Expect(filepath.Join(dir, "release", "cool.zip")).To(BeAnExistingFile())
+		})
+
+		It("reports the release of every file downloaded", func() {
+			results := []pivnetapi.DownloadResult{}
+			err := api.DownloadReleaseContext(context.Background(), "myprod", "1.0", dir, pivnetapi.DownloadOptions{
+				Done: func(result pivnetapi.DownloadResult) {
+					results = append(results, result)
+				},
+			})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results).To(HaveLen(3))
+			for _, result := range results {
+				Expect(result.ProductSlug).To(Equal("myprod"))
+				Expect(result.Release.Version).To(Equal("1.0"))
+			}
+			Expect(results[1].FileName).To(Equal(filepath.Join(dir, "product.pivotal")))
+		})
+
+		It("prefixes files sharing a name with their id", func() {
+			productFiles.Files[2].AwsObjectKey = "other/product.pivotal"
+
+			err := api.DownloadRelease("myprod", "1.0", dir)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filepath.Join(dir, "product.pivotal")).To(BeAnExistingFile())
+			Expect(filepath.Join(dir, "23-product.pivotal")).To(BeAnExistingFile())
+		})
+
+		It("returns an error if a download fails", func() {
+			requester.GetProductDownloadUrlContextReturns("", errors.New("err"))
+
+			err := api.DownloadRelease("myprod", "1.0", dir)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("ResolveDependencies", func() {
+		var stemcells *resource.Product
+		var stemcellFiles *resource.ProductFiles
+
+		BeforeEach(func() {
+			requester.GetReleaseDependenciesContextReturns(&resource.ReleaseDependencies{
+				Dependencies: []resource.ReleaseDependency{
+					{Release: resource.DependentRelease{Id: 31, Version: "3468.17", Product: resource.ProductSummary{Slug: "stemcells"}}},
+					{Release: resource.DependentRelease{Id: 33, Version: "3468.21", Product: resource.ProductSummary{Slug: "stemcells"}}},
+					{Release: resource.DependentRelease{Id: 34, Version: "3468.22-beta.1", Product: resource.ProductSummary{Slug: "stemcells"}}},
+					{Release: resource.DependentRelease{Id: 32, Version: "3468.20", Product: resource.ProductSummary{Slug: "stemcells"}}},
+				},
+			}, nil)
+
+			stemcells = &resource.Product{
+				Releases: []resource.Release{
+					{Id: 34, Version: "3468.22-beta.1"},
+					{Id: 33, Version: "3468.21"},
+					{Id: 32, Version: "3468.20"},
+					{Id: 31, Version: "3468.17"},
+				},
+			}
+			stemcellFiles = &resource.ProductFiles{
+				Files: []resource.ProductFile{
+					{Id: 41, AwsObjectKey: "bosh-stemcell-3468.21-vsphere-esxi-ubuntu-trusty-go_agent.tgz"},
+					{Id: 42, AwsObjectKey: "bosh-stemcell-3468.21-aws-xen-hvm-ubuntu-trusty-go_agent.tgz"},
+				},
+			}
+			requester.GetProductContextStub = func(ctx context.Context, slug string) (*resource.Product, error) {
+				if slug == "stemcells" {
+					return stemcells, nil
+				}
+				return prod, nil
+			}
+			requester.GetProductFilesContextStub = func(ctx context.Context, release resource.Release) (*resource.ProductFiles, error) {
+				if release.Id == 33 {
+					return stemcellFiles, nil
+				}
+				return productFiles, nil
+			}
+		})
+
+		It("returns the latest release of each dependency with its files", func() {
+			dependencies, err := api.ResolveDependencies("myprod", "1.0")
+
+			Expect(err).ToNot(HaveOccurred())
+			_, slug, releaseId := requester.GetReleaseDependenciesContextArgsForCall(0)
+			Expect(slug).To(Equal("myprod"))
+			Expect(releaseId).To(Equal(1))
+			Expect(dependencies).To(Equal([]pivnetapi.Dependency{
+				{
+					ProductSlug: "stemcells",
+					Release:     stemcells.Releases[1],
+					Files:       stemcellFiles.Files,
+				},
+			}))
+		})
+
+		It("returns an error if the dependency is not a release of its product", func() {
+			stemcells.Releases = stemcells.Releases[2:]
+
+			_, err := api.ResolveDependencies("myprod", "1.0")
+			Expect(errors.Is(err, resource.ErrNotFound)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("stemcells 3468.21"))
+		})
+
+		It("returns an error if it can't get the dependencies", func() {
+			requester.GetReleaseDependenciesContextReturns(nil, errors.New("err"))
+
+			_, err := api.ResolveDependencies("myprod", "1.0")
+			Expect(err).To(HaveOccurred())
+		})
+
+		Context("DownloadDependencies", func() {
+			var dir string
+			var server *ghttp.Server
+
+			BeforeEach(func() {
+				var err error
+				dir, err = ioutil.TempDir("", "")
+				Expect(err).ToNot(HaveOccurred())
+
+				server = ghttp.NewServer()
+				server.RouteToHandler("GET", "/", ghttp.RespondWith(http.StatusOK, `aaa`))
+				requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(dir)
+				server.Close()
+			})
+
+			It("downloads the files of the dependencies matching the globs", func() {
+				err := api.DownloadDependencies("myprod", "1.0", dir, []string{"*vsphere*"}, pivnetapi.DownloadOptions{})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(requester.GetProductDownloadUrlContextCallCount()).To(Equal(1))
+				Expect(filepath.Join(dir, "bosh-stemcell-3468.21-vsphere-esxi-ubuntu-trusty-go_agent.tgz")).To(BeAnExistingFile())
+			})
+
+			It("downloads every file of the dependencies without globs", func() {
+				err := api.DownloadDependencies("myprod", "1.0", dir, nil, pivnetapi.DownloadOptions{})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(requester.GetProductDownloadUrlContextCallCount()).To(Equal(2))
+			})
+
+			It("returns an error if no file matches the globs", func() {
+				err := api.DownloadDependencies("myprod", "1.0", dir, []string{"*openstack*"}, pivnetapi.DownloadOptions{})
+
+				Expect(err).To(MatchError(ContainSubstring("*openstack*")))
+				Expect(requester.GetProductDownloadUrlContextCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Context("PlanUpgrade", func() {
+		var upgradePaths map[int][]int
+
+		BeforeEach(func() {
+			prod.Releases = []resource.Release{
+				{Id: 7, Version: "2.0.0-beta.1"},
+				{Id: 6, Version: "1.10.3"},
+				{Id: 5, Version: "1.9.2"},
+				{Id: 4, Version: "1.9.0"},
+				{Id: 3, Version: "1.8.5"},
+				{Id: 2, Version: "1.8.0"},
+				{Id: 1, Version: "1.7.12"},
+			}
+			upgradePaths = map[int][]int{
+				6: {4, 5},
+				5: {2, 3},
+				4: {2, 3},
+				3: {1, 2},
+				2: {1},
+			}
+			requester.GetUpgradePathsContextStub = func(ctx context.Context, slug string, releaseId int) (*resource.UpgradePaths, error) {
+				paths := &resource.UpgradePaths{}
+				for _, id := range upgradePaths[releaseId] {
+					for _, release := range prod.Releases {
+						if release.Id == id {
+							paths.UpgradePaths = append(paths.UpgradePaths, resource.UpgradePath{Id: id, Version: release.Version})
+						}
+					}
+				}
+				return paths, nil
+			}
+		})
+
+		versions := func(releases []resource.Release) []string {
+			v := []string{}
+			for _, release := range releases {
+				v = append(v, release.Version)
+			}
+			return v
+		}
+
+		It("returns the shortest plan through the latest patches", func() {
+			plan, err := api.PlanUpgrade("myprod", "1.7.12", "1.10.3")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions(plan)).To(Equal([]string{"1.8.5", "1.9.2", "1.10.3"}))
+		})
+
+		It("upgrades to the latest release by default", func() {
+			plan, err := api.PlanUpgrade("myprod", "1.9.0", "")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions(plan)).To(Equal([]string{"1.10.3"}))
+		})
+
+		It("skips minors when an upgrade path allows it", func() {
+			upgradePaths[6] = append(upgradePaths[6], 1)
+
+			plan, err := api.PlanUpgrade("myprod", "1.7.12", "1.10.3")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions(plan)).To(Equal([]string{"1.10.3"}))
+			Expect(requester.GetUpgradePathsContextCallCount()).To(Equal(1))
+		})
+
+		It("returns an empty plan when already on the target release", func() {
+			plan, err := api.PlanUpgrade("myprod", "1.10.3", "1.10.3")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan).To(BeEmpty())
+		})
+
+		It("returns an error if no upgrade path leads to the target release", func() {
+			delete(upgradePaths, 2)
+			upgradePaths[3] = []int{2}
+
+			_, err := api.PlanUpgrade("myprod", "1.7.12", "1.10.3")
+
+			var noPath *pivnetapi.NoUpgradePathError
+			Expect(errors.As(err, &noPath)).To(BeTrue())
+			Expect(err).To(MatchError("No upgrade path from 1.7.12 to 1.10.3"))
+		})
+
+		It("returns an error if the target release is older", func() {
+			_, err := api.PlanUpgrade("myprod", "1.9.2", "1.8.5")
+			Expect(err).To(MatchError("Can't upgrade from 1.9.2 to the older 1.8.5"))
+		})
+
+		It("returns an error without a version to upgrade from", func() {
+			_, err := api.PlanUpgrade("myprod", "", "1.10.3")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns an error if it can't get the upgrade paths", func() {
+			requester.GetUpgradePathsContextStub = nil
+			requester.GetUpgradePathsContextReturns(nil, errors.New("err"))
+
+			_, err := api.PlanUpgrade("myprod", "1.7.12", "1.10.3")
+			Expect(err).To(MatchError("err"))
+		})
+	})
+
+	Context("GetVersionsForProduct", func() {
+		It("Returns an error if the product is empty", func() {
+			versions, err := api.GetVersionsForProduct("")
+			Expect(err).To(HaveOccurred())
+			Expect(versions).To(BeEmpty())
+		})
+
+		It("returns an error if it can't get the product from the server", func() {
+			requester.GetProductContextReturns(nil, errors.New("err"))
+
+			versions, err := api.GetVersionsForProduct("NonexistentProduct")
+			Expect(err).To(HaveOccurred())
+			Expect(versions).To(BeEmpty())
+		})
+
+		It("returns the list of versions for that product", func() {
+			versions, err := api.GetVersionsForProduct("ExistingProduct")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions).To(ContainElement("1.0"))
+			Expect(versions).To(ContainElement("2.0"))
+		})
+	})
+
+	Context("release filters", func() {
+		BeforeEach(func() {
+			prod.Releases = []resource.Release{
+				resource.Release{Id: 5, Version: "2.1.0", ReleaseType: "Beta Release", ReleaseDate: "2016-09-01", Availability: "Selected User Groups Only"},
+				resource.Release{Id: 4, Version: "2.0.0", ReleaseType: "Major Release", ReleaseDate: "2016-08-01", Availability: "All Users"},
+				resource.Release{Id: 3, Version: "1.8.2", ReleaseType: "Security Release", ReleaseDate: "2016-07-15", Availability: "All Users"},
+				resource.Release{Id: 2, Version: "1.8.0", ReleaseType: "Minor Release", ReleaseDate: "2016-06-01", Availability: "All Users"},
+				resource.Release{Id: 1, Version: "1.7.0", ReleaseType: "Minor Release"},
+			}
+		})
+
+		ids := func(releases []resource.Release) []int {
+			result := []int{}
+			for _, release := range releases {
+				result = append(result, release.Id)
+			}
+			return result
+		}
+
+		It("returns every release with an empty filter", func() {
+			releases, err := api.GetReleases("myprod", pivnetapi.ReleaseFilter{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ids(releases)).To(Equal([]int{5, 4, 3, 2, 1}))
+		})
+
+		It("returns an error if there is no product name", func() {
+			_, err := api.GetReleases("", pivnetapi.ReleaseFilter{})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("filters by release type", func() {
+			releases, err := api.GetReleases("myprod", pivnetapi.ReleaseFilter{ReleaseTypes: []string{"major", "MINOR"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ids(releases)).To(Equal([]int{4, 2, 1}))
+		})
+
+		It("filters out pre-GA releases", func() {
+			releases, err := api.GetReleases("myprod", pivnetapi.ReleaseFilter{GAOnly: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ids(releases)).To(Equal([]int{4, 3, 2, 1}))
+		})
+
+		It("filters by availability", func() {
+			releases, err := api.GetReleases("myprod", pivnetapi.ReleaseFilter{Availability: []string{"all users"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ids(releases)).To(Equal([]int{4, 3, 2}))
+		})
+
+		It("filters by release date and drops releases without one", func() {
+			filter := pivnetapi.ReleaseFilter{
+				ReleasedAfter:  time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC),
+				ReleasedBefore: time.Date(2016, 8, 15, 0, 0, 0, 0, time.UTC),
+			}
+			releases, err := api.GetReleases("myprod", filter)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ids(releases)).To(Equal([]int{4, 3}))
+		})
+
+		It("applies the api filter when resolving versions", func() {
+			api.ReleaseFilter = pivnetapi.ReleaseFilter{ReleasedBefore: time.Date(2016, 7, 1, 0, 0, 0, 0, time.UTC)}
+
+			res, err := api.ResolveVersion("myprod", "latest")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Id).To(Equal(2))
+
+			_, err = api.ResolveVersion("myprod", "2.0.0")
+			Expect(err).To(HaveOccurred())
+
+			versions, err := api.GetVersionsForProduct("myprod")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions).To(Equal([]string{"1.8.0"}))
 		})
 	})
 })
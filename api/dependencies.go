@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cfmobile/gopivnet/resource"
+	"github.com/cfmobile/gopivnet/semver"
+)
+
+// Dependency is the release of another product, such as a stemcell, that a
+// release needs, along with the files of that release.
+type Dependency struct {
+	ProductSlug string
+	Release     resource.Release
+	Files       []resource.ProductFile
+}
+
+// ResolveDependencies returns the dependencies of the release of the product
+// matching version, one per product sorted by slug. Pivotal Network lists
+// every compatible release of a dependency, such as each patch of a stemcell
+// line; the latest of them is picked.
+func (p *PivnetApi) ResolveDependencies(productName, version string) ([]Dependency, error) {
+	return p.ResolveDependenciesContext(context.Background(), productName, version)
+}
+
+func (p *PivnetApi) ResolveDependenciesContext(ctx context.Context, productName, version string) ([]Dependency, error) {
+	release, err := p.ResolveVersionContext(ctx, productName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	releaseDependencies, err := p.Requester.GetReleaseDependenciesContext(ctx, productName, release.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := map[string]resource.DependentRelease{}
+	for _, dependency := range releaseDependencies.Dependencies {
+		slug := dependency.Release.Product.Slug
+		current, ok := latest[slug]
+		if !ok || newerVersion(dependency.Release.Version, current.Version) {
+			latest[slug] = dependency.Release
+		}
+	}
+
+	slugs := []string{}
+	for slug := range latest {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	dependencies := []Dependency{}
+	for _, slug := range slugs {
+		dependency, err := p.resolveDependency(ctx, latest[slug])
+		if err != nil {
+			return nil, fmt.Errorf("Unable to resolve %s %s, a dependency of %s %s: %w", slug, latest[slug].Version, productName, release.Version, err)
+		}
+		dependencies = append(dependencies, *dependency)
+	}
+	return dependencies, nil
+}
+
+// resolveDependency looks the release up in its product, since the
+// dependency itself doesn't link to its files. The release filter doesn't
+// apply to dependencies.
+func (p *PivnetApi) resolveDependency(ctx context.Context, dependent resource.DependentRelease) (*Dependency, error) {
+	product, err := p.Requester.GetProductContext(ctx, dependent.Product.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, release := range product.Releases {
+		if release.Id != dependent.Id {
+			continue
+		}
+
+		productFiles, err := p.Requester.GetProductFilesContext(ctx, release)
+		if err != nil {
+			return nil, err
+		}
+		return &Dependency{
+			ProductSlug: dependent.Product.Slug,
+			Release:     release,
+			Files:       productFiles.Files,
+		}, nil
+	}
+	return nil, resource.ErrNotFound
+}
+
+// DownloadDependencies downloads the files of the dependencies of the release
+// matching version into destDir. With globs, e.g. "*vsphere*" to pick the
+// stemcell of an IaaS, only the files matching one of them are downloaded,
+// and dependencies without such files are skipped; without, every file is.
+func (p *PivnetApi) DownloadDependencies(productName, version, destDir string, globs []string, options DownloadOptions) error {
+	return p.DownloadDependenciesContext(context.Background(), productName, version, destDir, globs, options)
+}
+
+func (p *PivnetApi) DownloadDependenciesContext(ctx context.Context, productName, version, destDir string, globs []string, options DownloadOptions) error {
+	for _, glob := range globs {
+		_, err := path.Match(glob, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	dependencies, err := p.ResolveDependenciesContext(ctx, productName, version)
+	if err != nil {
+		return err
+	}
+
+	matches := 0
+	for index, dependency := range dependencies {
+		if len(globs) > 0 {
+			matched, err := SelectGlobs(&resource.ProductFiles{Files: dependency.Files}, "", globs)
+			if err != nil {
+				matched = nil
+			}
+			dependencies[index].Files = matched
+		}
+		matches += len(dependencies[index].Files)
+	}
+	if len(globs) > 0 && matches == 0 {
+		return fmt.Errorf("No file of the dependencies of %s matches %s", productName, strings.Join(globs, ", "))
+	}
+
+	err = os.MkdirAll(destDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	for _, dependency := range dependencies {
+		dependencyOptions := options.ForRelease(dependency.ProductSlug, dependency.Release)
+		for index := range dependency.Files {
+			productFile := &dependency.Files[index]
+			err = p.DownloadContext(ctx, productFile, filepath.Join(destDir, productFile.Name()), dependencyOptions)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// newerVersion reports whether version a is newer than b, preferring
+// releases to pre-releases and parseable versions to others.
+func newerVersion(a, b string) bool {
+	va, errA := semver.Parse(a)
+	vb, errB := semver.Parse(b)
+	switch {
+	case errA != nil:
+		return false
+	case errB != nil:
+		return true
+	case (va.Prerelease == "") != (vb.Prerelease == ""):
+		return va.Prerelease == ""
+	}
+	return vb.LessThan(va)
+}
@@ -0,0 +1,350 @@
+package api
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cfmobile/gopivnet/resource"
+)
+
+// PartialSuffix is appended to the target file name while a download is in
+// progress. A partial file left behind by a failed run is resumed by the next
+// download of the same file.
+const PartialSuffix = ".partial"
+
+type DownloadOptions struct {
+	// Parallel is the number of concurrent ranged requests used to fetch a
+	// single file. Values below 2 download over one connection.
+	Parallel int
+	// Progress, if set, is updated every ProgressInterval while the file
+	// downloads, and once more when it is done.
+	Progress         ProgressObserver
+	ProgressInterval time.Duration
+	// Done, if set, is called with the result of every file written.
+	Done func(result DownloadResult)
+	// Warn, if set, is called with failures that don't fail the download,
+	// such as a cache entry that can't be read or written.
+	Warn func(err error)
+	// Receipt writes a Receipt next to every file written.
+	Receipt bool
+
+	productSlug string
+	release     *resource.Release
+}
+
+// DownloadResult describes a file written by a download.
+type DownloadResult struct {
+	// ProductSlug and Release are set by the downloads that resolve a
+	// release, such as DownloadReleaseContext, or by ForRelease.
+	ProductSlug string
+	Release     *resource.Release
+	ProductFile *resource.ProductFile
+	FileName    string
+	Bytes       int64
+	Duration    time.Duration
+	// Cached is true if the file was copied from the cache instead of being
+	// downloaded.
+	Cached bool
+}
+
+// ForRelease returns options for downloading files of release, which it
+// sets in results and receipts.
+func (o DownloadOptions) ForRelease(productSlug string, release resource.Release) DownloadOptions {
+	o.productSlug = productSlug
+	o.release = &release
+	return o
+}
+
+func (o DownloadOptions) warn(err error) {
+	if o.Warn != nil {
+		o.Warn(err)
+	}
+}
+
+type ChecksumError struct {
+	FileName  string
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%s checksum mismatch for \"%s\": expected %s, got %s", e.Algorithm, e.FileName, e.Expected, e.Actual)
+}
+
+// retryableError marks failures caused by the connection rather than by the
+// request or the local file, which are worth resuming. resp is set when the
+// server answered with a retryable status.
+type retryableError struct {
+	err  error
+	resp *http.Response
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func download(ctx context.Context, client *http.Client, url string, productFile *resource.ProductFile, fileName string, options DownloadOptions, policy resource.RetryPolicy) error {
+	partialName := fileName + PartialSuffix
+	err := checkWritable(fileName, partialName)
+	if err != nil {
+		return err
+	}
+
+	var resumed int64
+	if info, err := os.Stat(partialName); err == nil && options.Parallel < 2 {
+		resumed = info.Size()
+	}
+	progress := startProgress(fileName, resumed, options)
+
+	_, err = fetch(ctx, client, url, partialName, hasChecksum(productFile), options, policy, progress)
+	progress.finish(err)
+	if err != nil {
+		if ctx.Err() != nil {
+			os.Remove(partialName)
+			return ctx.Err()
+		}
+		return err
+	}
+
+	err = verifyChecksum(productFile, partialName)
+	if err != nil {
+		os.Remove(partialName)
+		if checksumErr, ok := err.(*ChecksumError); ok {
+			checksumErr.FileName = fileName
+		}
+		return err
+	}
+
+	return os.Rename(partialName, fileName)
+}
+
+// checksummed tells fetch that the file is verified once downloaded.
+func fetch(ctx context.Context, client *http.Client, url, partialName string, checksummed bool, options DownloadOptions, policy resource.RetryPolicy, progress *progressTracker) (int64, error) {
+	if options.Parallel > 1 {
+		n, err := parallelDownload(ctx, client, url, partialName, options.Parallel, policy, progress)
+		if err != errRangesNotSupported {
+			return n, err
+		}
+	}
+	return sequentialDownload(ctx, client, url, partialName, checksummed, policy, progress)
+}
+
+func sequentialDownload(ctx context.Context, client *http.Client, url, partialName string, checksummed bool, policy resource.RetryPolicy, progress *progressTracker) (int64, error) {
+	var n int64
+	var err error
+	for attempt := 1; attempt <= policy.Attempts(); attempt++ {
+		n, err = resumeDownload(ctx, client, url, partialName, checksummed, progress)
+		if !waitBeforeRetry(ctx, err, attempt, policy) {
+			break
+		}
+	}
+	return n, err
+}
+
+// waitBeforeRetry sleeps for the policy's backoff and returns true if err is
+// worth another attempt.
+func waitBeforeRetry(ctx context.Context, err error, attempt int, policy resource.RetryPolicy) bool {
+	retryable, ok := err.(*retryableError)
+	if !ok || attempt >= policy.Attempts() || ctx.Err() != nil {
+		return false
+	}
+
+	timer := time.NewTimer(policy.Backoff(attempt, retryable.resp))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// checkWritable fails early if an existing target or the partial file can't
+// be written. The target itself is only created by renaming the complete
+// partial file, so a failed download never leaves an empty target behind.
+func checkWritable(fileName, partialName string) error {
+	target, err := os.OpenFile(fileName, os.O_WRONLY, 0)
+	if err == nil {
+		target.Close()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	out, err := os.OpenFile(partialName, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// resumeDownload fetches whatever is missing from partialName and returns the
+// size of the partial file once the server has nothing more to send.
+func resumeDownload(ctx context.Context, client *http.Client, url, partialName string, checksummed bool, progress *progressTracker) (int64, error) {
+	out, err := os.OpenFile(partialName, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	offset, err := out.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return offset, &retryableError{err: err}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		if resp.ContentLength >= 0 {
+			progress.setTotal(offset + resp.ContentLength)
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// A partial file of the object's size is complete only if all of it
+		// was written, which only the checksum verified afterwards proves.
+		if size, ok := unsatisfiedRangeSize(resp); ok && size == offset && checksummed {
+			return offset, nil
+		}
+		// The partial file is larger than the object, the server won't say
+		// how large it is or nothing can verify it, so it can't be trusted.
+		// Start over.
+		err = out.Truncate(0)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		out.Close()
+		return resumeDownload(ctx, client, url, partialName, checksummed, progress)
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		// The server ignored the range, so start over.
+		offset = 0
+		err = out.Truncate(0)
+		if err != nil {
+			return 0, err
+		}
+		_, err = out.Seek(0, io.SeekStart)
+		if err != nil {
+			return 0, err
+		}
+		progress.setTotal(resp.ContentLength)
+	case resource.RetryableStatus(resp.StatusCode):
+		return offset, &retryableError{err: fmt.Errorf("bad status code from server: %d", resp.StatusCode), resp: resp}
+	default:
+		return offset, fmt.Errorf("bad status code from server: %d", resp.StatusCode)
+	}
+
+	progress.at(offset)
+	body := &bodyReader{reader: progress.reader(resp.Body)}
+	n, err := io.Copy(out, body)
+	if err != nil {
+		if body.err != nil {
+			return offset + n, &retryableError{err: err}
+		}
+		return offset + n, err
+	}
+
+	return offset + n, nil
+}
+
+// unsatisfiedRangeSize returns the size of the object from the
+// "bytes */<size>" Content-Range of a 416 response.
+func unsatisfiedRangeSize(resp *http.Response) (int64, bool) {
+	contentRange := resp.Header.Get("Content-Range")
+	if !strings.HasPrefix(contentRange, "bytes */") {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(strings.TrimPrefix(contentRange, "bytes */"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return size, true
+}
+
+// bodyReader remembers read errors so they can be told apart from write
+// errors after io.Copy returns.
+type bodyReader struct {
+	reader io.Reader
+	err    error
+}
+
+func (b *bodyReader) Read(p []byte) (int, error) {
+	n, err := b.reader.Read(p)
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+	return n, err
+}
+
+// CheckFile verifies that fileName has the size and checksum published for
+// productFile, as far as they are published.
+func CheckFile(productFile *resource.ProductFile, fileName string) error {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return err
+	}
+	if productFile.Size > 0 && info.Size() != productFile.Size {
+		return fmt.Errorf("\"%s\" is %d bytes, expected %d", fileName, info.Size(), productFile.Size)
+	}
+	return verifyChecksum(productFile, fileName)
+}
+
+func hasChecksum(productFile *resource.ProductFile) bool {
+	return productFile.Sha256 != "" || productFile.Md5 != ""
+}
+
+func verifyChecksum(productFile *resource.ProductFile, fileName string) error {
+	var algorithm, expected string
+	var h hash.Hash
+	switch {
+	case productFile.Sha256 != "":
+		algorithm, expected, h = "sha256", productFile.Sha256, sha256.New()
+	case productFile.Md5 != "":
+		algorithm, expected, h = "md5", productFile.Md5, md5.New()
+	default:
+		return nil
+	}
+
+	in, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	_, err = io.Copy(h, in)
+	if err != nil {
+		return err
+	}
+
+	actual := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(actual, expected) {
+		return &ChecksumError{
+			FileName:  fileName,
+			Algorithm: algorithm,
+			Expected:  expected,
+			Actual:    actual,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cfmobile/gopivnet/resource"
+)
+
+// ReleaseDateFormat is the layout of resource.Release.ReleaseDate.
+const ReleaseDateFormat = "2006-01-02"
+
+var preGAReleaseTypes = []string{"alpha", "beta", "developer"}
+
+// ReleaseFilter narrows down the releases of a product. The zero value keeps
+// every release. Release types and availabilities are matched case
+// insensitively on substrings, so "major" matches "Major Release".
+type ReleaseFilter struct {
+	ReleaseTypes []string
+	Availability []string
+	// GAOnly drops alpha, beta and developer releases.
+	GAOnly bool
+	// ReleasedBefore and ReleasedAfter are exclusive bounds on the release
+	// date. Releases without a valid date are dropped when either is set.
+	ReleasedBefore time.Time
+	ReleasedAfter  time.Time
+}
+
+func (f ReleaseFilter) IsZero() bool {
+	return len(f.ReleaseTypes) == 0 && len(f.Availability) == 0 && !f.GAOnly &&
+		f.ReleasedBefore.IsZero() && f.ReleasedAfter.IsZero()
+}
+
+func (f ReleaseFilter) Matches(release resource.Release) bool {
+	if len(f.ReleaseTypes) > 0 && !containsAny(release.ReleaseType, f.ReleaseTypes) {
+		return false
+	}
+
+	if len(f.Availability) > 0 && !containsAny(release.Availability, f.Availability) {
+		return false
+	}
+
+	if f.GAOnly && containsAny(release.ReleaseType, preGAReleaseTypes) {
+		return false
+	}
+
+	if !f.ReleasedBefore.IsZero() || !f.ReleasedAfter.IsZero() {
+		date, err := time.Parse(ReleaseDateFormat, release.ReleaseDate)
+		if err != nil {
+			return false
+		}
+		if !f.ReleasedBefore.IsZero() && !date.Before(f.ReleasedBefore) {
+			return false
+		}
+		if !f.ReleasedAfter.IsZero() && !date.After(f.ReleasedAfter) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Apply returns the releases matching the filter, keeping their order.
+func (f ReleaseFilter) Apply(releases []resource.Release) []resource.Release {
+	matching := []resource.Release{}
+	for _, release := range releases {
+		if f.Matches(release) {
+			matching = append(matching, release)
+		}
+	}
+	return matching
+}
+
+func containsAny(value string, substrings []string) bool {
+	value = strings.ToLower(value)
+	for _, substring := range substrings {
+		if strings.Contains(value, strings.ToLower(substring)) {
+			return true
+		}
+	}
+	return false
+}
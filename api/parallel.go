@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cfmobile/gopivnet/resource"
+)
+
+var errRangesNotSupported = errors.New("server does not support ranged requests")
+
+type chunk struct {
+	start int64
+	end   int64
+}
+
+// parallelDownload splits the file into one chunk per connection and writes
+// each chunk in place into a pre-allocated partial file. Unlike
+// sequentialDownload it does not resume a partial file from a previous run,
+// and it removes the partial file when it fails: chunks are written out of
+// order, so a resume would take the gaps left by a failed chunk for data.
+func parallelDownload(ctx context.Context, client *http.Client, url, partialName string, parallel int, policy resource.RetryPolicy, progress *progressTracker) (int64, error) {
+	size, err := contentLength(ctx, client, url, policy)
+	if err != nil {
+		return 0, err
+	}
+	progress.at(0)
+	progress.setTotal(size)
+
+	out, err := os.OpenFile(partialName, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	err = out.Truncate(size)
+	if err != nil {
+		out.Close()
+		os.Remove(partialName)
+		return 0, err
+	}
+
+	// The first chunk to fail stops the others.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for _, c := range splitChunks(size, parallel) {
+		wg.Add(1)
+		go func(c chunk) {
+			defer wg.Done()
+			err := downloadChunk(ctx, client, url, out, c, policy, progress)
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		out.Close()
+		os.Remove(partialName)
+		return 0, firstErr
+	}
+	return size, nil
+}
+
+// contentLength asks for the first byte of the file, which tells us both
+// whether the server honours ranges and how large the file is. Signed S3 urls
+// only allow GET, so a HEAD request can't be used here.
+func contentLength(ctx context.Context, client *http.Client, url string, policy resource.RetryPolicy) (int64, error) {
+	var size int64
+	var err error
+	for attempt := 1; attempt <= policy.Attempts(); attempt++ {
+		size, err = probeRange(ctx, client, url)
+		if !waitBeforeRetry(ctx, err, attempt, policy) {
+			break
+		}
+	}
+	return size, err
+}
+
+func probeRange(ctx context.Context, client *http.Client, url string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, &retryableError{err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		// S3 answers 416 for any range of an empty object, which a single
+		// request downloads just as well.
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			return 0, errRangesNotSupported
+		}
+		err = fmt.Errorf("bad status code from server: %d", resp.StatusCode)
+		if resource.RetryableStatus(resp.StatusCode) {
+			return 0, &retryableError{err: err, resp: resp}
+		}
+		return 0, err
+	}
+
+	contentRange := resp.Header.Get("Content-Range")
+	slash := strings.LastIndex(contentRange, "/")
+	if slash < 0 {
+		return 0, errRangesNotSupported
+	}
+	size, err := strconv.ParseInt(contentRange[slash+1:], 10, 64)
+	if err != nil {
+		return 0, errRangesNotSupported
+	}
+	return size, nil
+}
+
+func splitChunks(size int64, parallel int) []chunk {
+	if int64(parallel) > size {
+		parallel = int(size)
+	}
+	if parallel < 1 {
+		return nil
+	}
+
+	chunkSize := (size + int64(parallel) - 1) / int64(parallel)
+	var chunks []chunk
+	for start := int64(0); start < size; start += chunkSize {
+		end := start + chunkSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		chunks = append(chunks, chunk{start: start, end: end})
+	}
+	return chunks
+}
+
+func downloadChunk(ctx context.Context, client *http.Client, url string, out *os.File, c chunk, policy resource.RetryPolicy, progress *progressTracker) error {
+	var err error
+	for attempt := 1; attempt <= policy.Attempts(); attempt++ {
+		var n int64
+		n, err = fetchRange(ctx, client, url, out, c, progress)
+		c.start += n
+		if !waitBeforeRetry(ctx, err, attempt, policy) {
+			break
+		}
+	}
+	return err
+}
+
+func fetchRange(ctx context.Context, client *http.Client, url string, out *os.File, c chunk, progress *progressTracker) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", c.start, c.end))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, &retryableError{err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		err = fmt.Errorf("bad status code from server: %d", resp.StatusCode)
+		if resource.RetryableStatus(resp.StatusCode) {
+			return 0, &retryableError{err: err, resp: resp}
+		}
+		return 0, err
+	}
+
+	body := &bodyReader{reader: progress.reader(io.LimitReader(resp.Body, c.end-c.start+1))}
+	n, err := io.Copy(io.NewOffsetWriter(out, c.start), body)
+	if err != nil {
+		if body.err != nil {
+			return n, &retryableError{err: err}
+		}
+		return n, err
+	}
+	if n < c.end-c.start+1 {
+		return n, &retryableError{err: io.ErrUnexpectedEOF}
+	}
+	return n, nil
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cfmobile/gopivnet/resource"
+)
+
+const defaultFileType = "Software"
+
+// CreateRelease creates a release of the product. With userGroupIds, the
+// release is restricted to those user groups.
+func (p *PivnetApi) CreateRelease(productName string, release resource.NewRelease, userGroupIds []int) (*resource.Release, error) {
+	return p.CreateReleaseContext(context.Background(), productName, release, userGroupIds)
+}
+
+func (p *PivnetApi) CreateReleaseContext(ctx context.Context, productName string, release resource.NewRelease, userGroupIds []int) (*resource.Release, error) {
+	if productName == "" {
+		return nil, errors.New("Must specify a product name")
+	}
+
+	if release.Version == "" {
+		return nil, errors.New("Must specify a product version")
+	}
+
+	created, err := p.Requester.CreateReleaseContext(ctx, productName, release)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(userGroupIds) > 0 {
+		err = p.Requester.UpdateReleaseAvailabilityContext(ctx, productName, created.Id, "Selected User Groups Only", userGroupIds)
+		if err != nil {
+			return nil, err
+		}
+		created.Availability = "Selected User Groups Only"
+	}
+	return created, nil
+}
+
+// UploadProductFile uploads fileName to Pivotal Network and adds it to the
+// release with exactly that version, since a constraint could pick a release
+// other than the one meant. Empty fields of productFile are filled in: the
+// name and object key from the file name, the file version from the release,
+// the file type as "Software", and the checksums from the file contents.
+func (p *PivnetApi) UploadProductFile(productName, version, fileName string, productFile resource.NewProductFile) (*resource.ProductFile, error) {
+	return p.UploadProductFileContext(context.Background(), productName, version, fileName, productFile)
+}
+
+func (p *PivnetApi) UploadProductFileContext(ctx context.Context, productName, version, fileName string, productFile resource.NewProductFile) (*resource.ProductFile, error) {
+	if version == "" {
+		return nil, errors.New("Must specify a product version")
+	}
+
+	release, err := p.exactRelease(ctx, productName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	in, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	productFile.Sha256, productFile.Md5, err = fileChecksums(in)
+	if err != nil {
+		return nil, err
+	}
+	_, err = in.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	base := filepath.Base(fileName)
+	if productFile.Name == "" {
+		productFile.Name = base
+	}
+	if productFile.AwsObjectKey == "" {
+		productFile.AwsObjectKey = fmt.Sprintf("product-files/%s/%s", productName, base)
+	}
+	if productFile.FileVersion == "" {
+		productFile.FileVersion = release.Version
+	}
+	if productFile.FileType == "" {
+		productFile.FileType = defaultFileType
+	}
+
+	err = p.Requester.UploadFileContext(ctx, productName, productFile.AwsObjectKey, in)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := p.Requester.CreateProductFileContext(ctx, productName, productFile)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.Requester.AddProductFileContext(ctx, productName, release.Id, created.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// exactRelease returns the release of the product with exactly version,
+// whatever the release filter.
+func (p *PivnetApi) exactRelease(ctx context.Context, productName, version string) (*resource.Release, error) {
+	product, err := p.Requester.GetProductContext(ctx, productName)
+	if err != nil {
+		return nil, p.unknownProduct(ctx, productName, err)
+	}
+
+	for index, release := range product.Releases {
+		if release.Version == version {
+			return &product.Releases[index], nil
+		}
+	}
+	return nil, fmt.Errorf("No release %s of %s", version, productName)
+}
+
+func fileChecksums(in io.Reader) (string, string, error) {
+	sha := sha256.New()
+	md := md5.New()
+	_, err := io.Copy(io.MultiWriter(sha, md), in)
+	if err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(sha.Sum(nil)), hex.EncodeToString(md.Sum(nil)), nil
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultProgressInterval is how often a ProgressObserver is updated unless
+// DownloadOptions.ProgressInterval says otherwise.
+const DefaultProgressInterval = time.Second
+
+// Progress is a snapshot of a download in flight.
+type Progress struct {
+	FileName string
+	// Done includes the bytes of a partial file resumed from an earlier run.
+	Done int64
+	// Total is 0 until the server has told us the size of the file.
+	Total   int64
+	Elapsed time.Duration
+	// Rate is the average number of bytes per second fetched by this run.
+	Rate float64
+	// Complete is set on the last update, along with Err if the download
+	// failed.
+	Complete bool
+	Err      error
+}
+
+// ETA estimates the time left, or returns 0 if it can't be estimated.
+func (p Progress) ETA() time.Duration {
+	if p.Total <= 0 || p.Rate <= 0 || p.Done >= p.Total {
+		return 0
+	}
+	return time.Duration(float64(p.Total-p.Done) / p.Rate * float64(time.Second))
+}
+
+type ProgressObserver interface {
+	Update(progress Progress)
+}
+
+type ProgressFunc func(progress Progress)
+
+func (f ProgressFunc) Update(progress Progress) {
+	f(progress)
+}
+
+// progressTracker counts the bytes written by a download and reports them to
+// the observer every interval. A nil tracker counts nothing.
+type progressTracker struct {
+	fileName string
+	observer ProgressObserver
+	start    time.Time
+	done     int64
+	resumed  int64
+	total    int64
+	stop     chan struct{}
+	stopped  sync.WaitGroup
+}
+
+// startProgress starts reporting the progress of a download of fileName, of
+// which resumed bytes were fetched by an earlier run.
+func startProgress(fileName string, resumed int64, options DownloadOptions) *progressTracker {
+	if options.Progress == nil {
+		return nil
+	}
+
+	interval := options.ProgressInterval
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+
+	t := &progressTracker{
+		fileName: fileName,
+		observer: options.Progress,
+		start:    time.Now(),
+		done:     resumed,
+		resumed:  resumed,
+		stop:     make(chan struct{}),
+	}
+
+	t.stopped.Add(1)
+	go func() {
+		defer t.stopped.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				t.observer.Update(t.snapshot())
+			case <-t.stop:
+				return
+			}
+		}
+	}()
+	return t
+}
+
+// finish sends the last update once the periodic updates have stopped.
+func (t *progressTracker) finish(err error) {
+	if t == nil {
+		return
+	}
+	close(t.stop)
+	t.stopped.Wait()
+
+	progress := t.snapshot()
+	progress.Complete = true
+	progress.Err = err
+	t.observer.Update(progress)
+}
+
+func (t *progressTracker) snapshot() Progress {
+	done := atomic.LoadInt64(&t.done)
+	elapsed := time.Since(t.start)
+
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(done-atomic.LoadInt64(&t.resumed)) / elapsed.Seconds()
+	}
+	return Progress{
+		FileName: t.fileName,
+		Done:     done,
+		Total:    atomic.LoadInt64(&t.total),
+		Elapsed:  elapsed,
+		Rate:     rate,
+	}
+}
+
+// at moves the count to offset when a download (re)starts from there.
+func (t *progressTracker) at(offset int64) {
+	if t == nil {
+		return
+	}
+	atomic.StoreInt64(&t.done, offset)
+	if offset < atomic.LoadInt64(&t.resumed) {
+		atomic.StoreInt64(&t.resumed, offset)
+	}
+}
+
+func (t *progressTracker) setTotal(total int64) {
+	if t == nil || total <= 0 {
+		return
+	}
+	atomic.StoreInt64(&t.total, total)
+}
+
+func (t *progressTracker) reader(r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	return &countingReader{reader: r, count: &t.done}
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  *int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	atomic.AddInt64(c.count, int64(n))
+	return n, err
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cfmobile/gopivnet/cache"
+	"github.com/cfmobile/gopivnet/resource"
+)
+
+// ReceiptSuffix is appended to the name of a downloaded file to name its
+// receipt.
+const ReceiptSuffix = ".pivnet.json"
+
+// Receipt records where a downloaded file comes from, so it can be verified
+// later. It is written next to the file when DownloadOptions.Receipt is set.
+type Receipt struct {
+	Product        string    `json:"product"`
+	ReleaseId      int       `json:"release_id"`
+	ReleaseVersion string    `json:"release_version,omitempty"`
+	ProductFileId  int       `json:"product_file_id"`
+	FileVersion    string    `json:"file_version"`
+	AwsObjectKey   string    `json:"aws_object_key"`
+	Size           int64     `json:"size"`
+	Sha256         string    `json:"sha256"`
+	Md5            string    `json:"md5,omitempty"`
+	DownloadedAt   time.Time `json:"downloaded_at"`
+}
+
+// ReceiptMismatchError is returned when a file, its receipt and Pivotal
+// Network disagree about a field.
+type ReceiptMismatchError struct {
+	FileName string
+	Field    string
+	Expected string
+	Actual   string
+}
+
+func (e *ReceiptMismatchError) Error() string {
+	return fmt.Sprintf("%s of \"%s\" doesn't match: expected %s, got %s", e.Field, e.FileName, e.Expected, e.Actual)
+}
+
+func ReceiptName(fileName string) string {
+	return fileName + ReceiptSuffix
+}
+
+// ReadReceipt reads the receipt of fileName.
+func ReadReceipt(fileName string) (*Receipt, error) {
+	content, err := ioutil.ReadFile(ReceiptName(fileName))
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := Receipt{}
+	err = json.Unmarshal(content, &receipt)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid receipt \"%s\": %w", ReceiptName(fileName), err)
+	}
+	return &receipt, nil
+}
+
+// Check verifies that fileName has the size and checksum of the receipt.
+func (r *Receipt) Check(fileName string) error {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return err
+	}
+	if info.Size() != r.Size {
+		return &ReceiptMismatchError{FileName: fileName, Field: "size", Expected: fmt.Sprint(r.Size), Actual: fmt.Sprint(info.Size())}
+	}
+
+	sha, md, err := fileChecksum(fileName)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(sha, r.Sha256) {
+		return &ChecksumError{FileName: fileName, Algorithm: "sha256", Expected: r.Sha256, Actual: sha}
+	}
+	if r.Md5 != "" && !strings.EqualFold(md, r.Md5) {
+		return &ChecksumError{FileName: fileName, Algorithm: "md5", Expected: r.Md5, Actual: md}
+	}
+	return nil
+}
+
+// VerifyFile checks fileName against its receipt, and the receipt against
+// the file as Pivotal Network describes it now, e.g. to catch a file that
+// was replaced since it was downloaded.
+func (p *PivnetApi) VerifyFile(fileName string) (*Receipt, error) {
+	return p.VerifyFileContext(context.Background(), fileName)
+}
+
+func (p *PivnetApi) VerifyFileContext(ctx context.Context, fileName string) (*Receipt, error) {
+	receipt, err := ReadReceipt(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = receipt.Check(fileName)
+	if err != nil {
+		return receipt, err
+	}
+	if receipt.Product == "" || receipt.ReleaseId == 0 {
+		return receipt, fmt.Errorf("Receipt \"%s\" doesn't record the product and release of the file, it can only be checked offline", ReceiptName(fileName))
+	}
+
+	productFile, err := p.Requester.GetProductFileContext(ctx, receipt.Product, receipt.ReleaseId, receipt.ProductFileId)
+	if err != nil {
+		return receipt, err
+	}
+
+	mismatch := func(field, expected, actual string) error {
+		return &ReceiptMismatchError{FileName: fileName, Field: field, Expected: expected, Actual: actual}
+	}
+	switch {
+	case productFile.Sha256 != "" && !strings.EqualFold(productFile.Sha256, receipt.Sha256):
+		return receipt, &ChecksumError{FileName: fileName, Algorithm: "sha256", Expected: productFile.Sha256, Actual: receipt.Sha256}
+	case productFile.Sha256 == "" && productFile.Md5 != "" && !strings.EqualFold(productFile.Md5, receipt.Md5):
+		return receipt, &ChecksumError{FileName: fileName, Algorithm: "md5", Expected: productFile.Md5, Actual: receipt.Md5}
+	case productFile.AwsObjectKey != receipt.AwsObjectKey:
+		return receipt, mismatch("aws_object_key", productFile.AwsObjectKey, receipt.AwsObjectKey)
+	case productFile.FileVersion != receipt.FileVersion:
+		return receipt, mismatch("file_version", productFile.FileVersion, receipt.FileVersion)
+	}
+	return receipt, nil
+}
+
+// writeReceipt records productFile as the origin of fileName. The product and
+// release come from options if set with ForRelease, from the download link
+// of productFile otherwise, and are left empty if neither has them.
+func writeReceipt(options DownloadOptions, productFile *resource.ProductFile, fileName string) error {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return err
+	}
+
+	receipt := Receipt{
+		ProductFileId: productFile.Id,
+		FileVersion:   productFile.FileVersion,
+		AwsObjectKey:  productFile.AwsObjectKey,
+		Size:          info.Size(),
+		Sha256:        productFile.Sha256,
+		Md5:           productFile.Md5,
+		DownloadedAt:  time.Now().UTC(),
+	}
+	if key, ok := cache.KeyFor(productFile); ok {
+		receipt.Product, receipt.ReleaseId = key.ProductSlug, key.ReleaseId
+	}
+	if options.release != nil {
+		receipt.Product = options.productSlug
+		receipt.ReleaseId = options.release.Id
+		receipt.ReleaseVersion = options.release.Version
+	}
+	if receipt.Sha256 == "" {
+		// The file was checked against its md5 at most, record a stronger
+		// checksum for later checks.
+		receipt.Sha256, _, err = fileChecksum(fileName)
+		if err != nil {
+			return err
+		}
+	}
+
+	content, err := json.MarshalIndent(receipt, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(ReceiptName(fileName), append(content, '\n'), 0644)
+}
+
+func fileChecksum(fileName string) (string, string, error) {
+	in, err := os.Open(fileName)
+	if err != nil {
+		return "", "", err
+	}
+	defer in.Close()
+	return fileChecksums(in)
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/cfmobile/gopivnet/resource"
+)
+
+// maxSuggestions is the number of slugs suggested for a mistyped one.
+const maxSuggestions = 3
+
+// UnknownProductError is returned when no product has the requested slug. It
+// unwraps to resource.ErrNotFound.
+type UnknownProductError struct {
+	Slug string
+	// Suggestions are the slugs of the products closest to Slug, best first.
+	Suggestions []string
+}
+
+func (e *UnknownProductError) Error() string {
+	msg := fmt.Sprintf("Product \"%s\" not found", e.Slug)
+	if len(e.Suggestions) > 0 {
+		msg += fmt.Sprintf(", did you mean \"%s\"?", strings.Join(e.Suggestions, "\", \""))
+	}
+	return msg
+}
+
+func (e *UnknownProductError) Unwrap() error {
+	return resource.ErrNotFound
+}
+
+// SearchProducts returns the products whose slug or name matches query, best
+// matches first. Matching ignores case and punctuation, so "p_redis" and
+// "Redis" both find p-redis, and tolerates a few typos.
+func (p *PivnetApi) SearchProducts(query string) ([]resource.ProductSummary, error) {
+	return p.SearchProductsContext(context.Background(), query)
+}
+
+func (p *PivnetApi) SearchProductsContext(ctx context.Context, query string) ([]resource.ProductSummary, error) {
+	products, err := p.GetProductsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return searchProducts(products, query), nil
+}
+
+type productMatch struct {
+	product resource.ProductSummary
+	score   int
+}
+
+func searchProducts(products []resource.ProductSummary, query string) []resource.ProductSummary {
+	normalizedQuery := normalize(query)
+
+	matches := []productMatch{}
+	for _, product := range products {
+		score, ok := matchScore(product, normalizedQuery)
+		if ok {
+			matches = append(matches, productMatch{product: product, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return matches[i].product.Slug < matches[j].product.Slug
+	})
+
+	found := []resource.ProductSummary{}
+	for _, match := range matches {
+		found = append(found, match.product)
+	}
+	return found
+}
+
+// matchScore ranks exact matches first, then prefixes, then substrings, then
+// slugs and names within a few typos of the query. Lower is better.
+func matchScore(product resource.ProductSummary, query string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+
+	best, ok := 0, false
+	for _, candidate := range []string{normalize(product.Slug), normalize(product.Name)} {
+		var score int
+		switch {
+		case candidate == "":
+			continue
+		case candidate == query:
+			score = 0
+		case strings.HasPrefix(candidate, query):
+			score = 1
+		case strings.Contains(candidate, query):
+			score = 2
+		default:
+			distance := editDistance(candidate, query)
+			if distance > maxTypos(query) {
+				continue
+			}
+			score = 2 + distance
+		}
+		if !ok || score < best {
+			best, ok = score, true
+		}
+	}
+	return best, ok
+}
+
+func maxTypos(query string) int {
+	if len(query) < 6 {
+		return 1
+	}
+	return len(query) / 3
+}
+
+// normalize lowercases s and drops everything but letters and digits.
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// unknownProduct turns the not found error of a product into an
+// UnknownProductError suggesting similar slugs. Other errors are returned as
+// they are.
+func (p *PivnetApi) unknownProduct(ctx context.Context, slug string, err error) error {
+	if !errors.Is(err, resource.ErrNotFound) {
+		return err
+	}
+
+	unknown := &UnknownProductError{Slug: slug}
+	products, listErr := p.Requester.GetProductsContext(ctx)
+	if listErr != nil || products == nil {
+		return unknown
+	}
+
+	for _, product := range searchProducts(products.Products, slug) {
+		if len(unknown.Suggestions) == maxSuggestions {
+			break
+		}
+		if product.Slug != slug {
+			unknown.Suggestions = append(unknown.Suggestions, product.Slug)
+		}
+	}
+	return unknown
+}
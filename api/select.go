@@ -0,0 +1,132 @@
+package api
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/cfmobile/gopivnet/resource"
+)
+
+// FileSelector picks product files out of a release. Every field that is set
+// has to match; an empty selector matches every file.
+type FileSelector struct {
+	// FileType matches files whose object key contains "."+FileType, like
+	// the fileType argument of GetLatestProductFile.
+	FileType string
+	// Glob and Regex are matched against ProductFile.Name().
+	Glob  string
+	Regex string
+	// Name is the product file name shown on Pivotal Network.
+	Name string
+	Id   int
+}
+
+type AmbiguousMatchError struct {
+	Candidates []string
+}
+
+func (e *AmbiguousMatchError) Error() string {
+	return fmt.Sprintf("%d files match, narrow the selection to one of: %s", len(e.Candidates), strings.Join(e.Candidates, ", "))
+}
+
+type NoMatchError struct {
+	Available []string
+}
+
+func (e *NoMatchError) Error() string {
+	return fmt.Sprintf("No file matches, available files are: %s", strings.Join(e.Available, ", "))
+}
+
+// SelectFiles returns the files matching selector, or a *NoMatchError listing
+// the available files if there are none.
+func SelectFiles(productFiles *resource.ProductFiles, selector FileSelector) ([]resource.ProductFile, error) {
+	var re *regexp.Regexp
+	if selector.Regex != "" {
+		var err error
+		re, err = regexp.Compile(selector.Regex)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if selector.Glob != "" {
+		_, err := path.Match(selector.Glob, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	files := []resource.ProductFile{}
+	for _, productFile := range productFiles.Files {
+		if selector.matches(&productFile, re) {
+			files = append(files, productFile)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, &NoMatchError{Available: fileNames(productFiles.Files)}
+	}
+	return files, nil
+}
+
+// SelectGlobs returns the files of fileType, if not empty, matching any of
+// globs, in the order of productFiles and without duplicates.
+func SelectGlobs(productFiles *resource.ProductFiles, fileType string, globs []string) ([]resource.ProductFile, error) {
+	files := []resource.ProductFile{}
+	seen := map[int]bool{}
+	for _, glob := range globs {
+		matched, err := SelectFiles(productFiles, FileSelector{
+			FileType: fileType,
+			Glob:     glob,
+		})
+		if _, ok := err.(*NoMatchError); ok {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, productFile := range matched {
+			if !seen[productFile.Id] {
+				seen[productFile.Id] = true
+				files = append(files, productFile)
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("No file matches %s", strings.Join(globs, ", "))
+	}
+	return files, nil
+}
+
+func (s FileSelector) matches(productFile *resource.ProductFile, re *regexp.Regexp) bool {
+	if s.FileType != "" && !strings.Contains(productFile.AwsObjectKey, "."+s.FileType) {
+		return false
+	}
+	if s.Glob != "" {
+		matched, _ := path.Match(s.Glob, productFile.Name())
+		if !matched {
+			return false
+		}
+	}
+	if re != nil && !re.MatchString(productFile.Name()) {
+		return false
+	}
+	if s.Name != "" && s.Name != productFile.DisplayName {
+		return false
+	}
+	if s.Id != 0 && s.Id != productFile.Id {
+		return false
+	}
+	return true
+}
+
+func fileNames(files []resource.ProductFile) []string {
+	names := []string{}
+	for index := range files {
+		names = append(names, files[index].Name())
+	}
+	return names
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// transport gathers the options deciding how New connects to Pivotal Network
+// and to the buckets files are downloaded from.
+type transport struct {
+	client             *http.Client
+	proxy              *url.URL
+	rootCAs            *x509.CertPool
+	insecureSkipVerify bool
+}
+
+// WithHttpClient sends every request, to Pivotal Network and downloads alike,
+// with client. WithProxy, WithRootCAs and WithInsecureSkipVerify are ignored
+// when it is set.
+func WithHttpClient(client *http.Client) Option {
+	return func(c *config) {
+		c.transport.client = client
+	}
+}
+
+// WithProxy sends every request through proxy instead of the proxy from the
+// HTTPS_PROXY environment. Credentials in the url authenticate with the proxy.
+func WithProxy(proxy *url.URL) Option {
+	return func(c *config) {
+		c.transport.proxy = proxy
+	}
+}
+
+// WithRootCAs verifies servers against pool instead of the system roots, see
+// LoadCABundle.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(c *config) {
+		c.transport.rootCAs = pool
+	}
+}
+
+// WithInsecureSkipVerify accepts any certificate. Only use it in a lab.
+func WithInsecureSkipVerify(skip bool) Option {
+	return func(c *config) {
+		c.transport.insecureSkipVerify = skip
+	}
+}
+
+// LoadCABundle returns the system roots plus the PEM encoded certificates in
+// fileName, for WithRootCAs.
+func LoadCABundle(fileName string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("No certificates found in \"%s\"", fileName)
+	}
+	return pool, nil
+}
+
+func (t transport) httpClient() *http.Client {
+	if t.client != nil {
+		return t.client
+	}
+	if t.proxy == nil && t.rootCAs == nil && !t.insecureSkipVerify {
+		return http.DefaultClient
+	}
+
+	httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+	if t.proxy != nil {
+		httpTransport.Proxy = http.ProxyURL(t.proxy)
+	}
+	if t.rootCAs != nil || t.insecureSkipVerify {
+		httpTransport.TLSClientConfig = &tls.Config{
+			RootCAs:            t.rootCAs,
+			InsecureSkipVerify: t.insecureSkipVerify,
+		}
+	}
+	return &http.Client{Transport: httpTransport}
+}
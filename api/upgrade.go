@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/cfmobile/gopivnet/resource"
+	"github.com/cfmobile/gopivnet/semver"
+)
+
+// NoUpgradePathError is returned when no sequence of upgrade paths leads from
+// one release to another.
+type NoUpgradePathError struct {
+	From string
+	To   string
+}
+
+func (e *NoUpgradePathError) Error() string {
+	return fmt.Sprintf("No upgrade path from %s to %s", e.From, e.To)
+}
+
+// PlanUpgrade returns the releases to install, in order, to upgrade the
+// product from the release matching from to the one matching to, which is
+// last. Each release can be upgraded to from the one before it according to
+// the upgrade paths of Pivotal Network, and the plan has as few releases as
+// possible, preferring the latest patches when several plans are as short.
+func (p *PivnetApi) PlanUpgrade(productName, from, to string) ([]resource.Release, error) {
+	return p.PlanUpgradeContext(context.Background(), productName, from, to)
+}
+
+func (p *PivnetApi) PlanUpgradeContext(ctx context.Context, productName, from, to string) ([]resource.Release, error) {
+	if productName == "" {
+		return nil, errors.New("Must specify a product name")
+	}
+	if from == "" {
+		return nil, errors.New("Must specify the version to upgrade from")
+	}
+
+	prod, err := p.getProduct(ctx, productName)
+	if err != nil {
+		return nil, err
+	}
+	fromRelease, err := resolveRelease(prod, from)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", from, err)
+	}
+	toRelease, err := resolveRelease(prod, to)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", to, err)
+	}
+	if fromRelease.Id == toRelease.Id {
+		return []resource.Release{}, nil
+	}
+	fromVersion, fromErr := semver.Parse(fromRelease.Version)
+	toVersion, toErr := semver.Parse(toRelease.Version)
+	if fromErr == nil && toErr == nil && !fromVersion.LessThan(toVersion) {
+		return nil, fmt.Errorf("Can't upgrade from %s to the older %s", fromRelease.Version, toRelease.Version)
+	}
+
+	releases := map[int]resource.Release{}
+	for _, release := range prod.Releases {
+		releases[release.Id] = release
+	}
+
+	// Search backwards from the target, since upgrade paths list the
+	// releases a release can be upgraded from. next links each release
+	// found to the release to upgrade to from it.
+	next := map[int]int{toRelease.Id: 0}
+	queue := []int{toRelease.Id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		upgradePaths, err := p.Requester.GetUpgradePathsContext(ctx, productName, current)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, previous := range candidates(upgradePaths.UpgradePaths, releases, fromVersion) {
+			if _, found := next[previous.Id]; found {
+				continue
+			}
+			next[previous.Id] = current
+			if previous.Id == fromRelease.Id {
+				return upgradePlan(releases, next, fromRelease.Id), nil
+			}
+			queue = append(queue, previous.Id)
+		}
+	}
+	return nil, &NoUpgradePathError{From: fromRelease.Version, To: toRelease.Version}
+}
+
+// candidates returns the releases of upgradePaths that are still available
+// and not older than from, latest first.
+func candidates(upgradePaths []resource.UpgradePath, releases map[int]resource.Release, from semver.Version) []resource.Release {
+	found := []resource.Release{}
+	for _, upgradePath := range upgradePaths {
+		release, ok := releases[upgradePath.Id]
+		if !ok {
+			continue
+		}
+		v, err := semver.Parse(release.Version)
+		if err == nil && len(from.Segments) > 0 && v.LessThan(from) {
+			continue
+		}
+		found = append(found, release)
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return newerVersion(found[i].Version, found[j].Version)
+	})
+	return found
+}
+
+func upgradePlan(releases map[int]resource.Release, next map[int]int, from int) []resource.Release {
+	plan := []resource.Release{}
+	for id := next[from]; id != 0; id = next[id] {
+		plan = append(plan, releases[id])
+	}
+	return plan
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/cfmobile/gopivnet/resource"
+	"github.com/cfmobile/gopivnet/semver"
+)
+
+const latestVersion = "latest"
+
+func resolveRelease(product *resource.Product, constraint string) (*resource.Release, error) {
+	if constraint == "" || constraint == latestVersion {
+		return latestRelease(product)
+	}
+
+	for index, release := range product.Releases {
+		if release.Version == constraint {
+			return &product.Releases[index], nil
+		}
+	}
+
+	c, err := semver.ParseConstraint(constraint)
+	if err != nil {
+		return nil, err
+	}
+
+	var match *resource.Release
+	var matchVersion semver.Version
+	for index, release := range product.Releases {
+		v, err := semver.Parse(release.Version)
+		if err != nil || !c.Check(v) {
+			continue
+		}
+		if match == nil || matchVersion.LessThan(v) {
+			match, matchVersion = &product.Releases[index], v
+		}
+	}
+
+	if match == nil {
+		return nil, errors.New("Specified version not found")
+	}
+	return match, nil
+}
+
+// latestRelease returns the highest release that isn't a pre-release. If no
+// release has a version we can parse, the first release returned by Pivotal
+// Network is used.
+func latestRelease(product *resource.Product) (*resource.Release, error) {
+	if len(product.Releases) == 0 {
+		return nil, errors.New("No releases found for product")
+	}
+
+	var latest *resource.Release
+	var highest semver.Version
+	for index, release := range product.Releases {
+		v, err := semver.Parse(release.Version)
+		if err != nil || v.Prerelease != "" {
+			continue
+		}
+		if latest == nil || highest.LessThan(v) {
+			latest, highest = &product.Releases[index], v
+		}
+	}
+
+	if latest == nil {
+		return &product.Releases[0], nil
+	}
+	return latest, nil
+}
@@ -0,0 +1,319 @@
+// Package cache keeps downloaded product files on disk so that later
+// downloads of the same file are served locally.
+package cache
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cfmobile/gopivnet/resource"
+)
+
+// noChecksum stands in for the checksum of files Pivotal Network publishes
+// none for. Such files are never cached, since a cached copy of them can't
+// be verified.
+const noChecksum = "none"
+
+var errCorrupt = errors.New("cached file is corrupt")
+
+// Key identifies a product file. Files are stored under
+// <dir>/<slug>/<release id>/<file id>/<checksum>/<name>, so a file that is
+// republished with new content gets a new entry.
+type Key struct {
+	ProductSlug string
+	ReleaseId   int
+	FileId      int
+	Checksum    string
+}
+
+// KeyFor returns the key of productFile, read from its download link
+// .../products/<slug>/releases/<release id>/product_files/<file id>/download.
+// It returns false if the link doesn't have that shape.
+func KeyFor(productFile *resource.ProductFile) (Key, bool) {
+	link, ok := productFile.Links["download"]
+	if !ok {
+		return Key{}, false
+	}
+
+	tokens := strings.Split(link.Url, "/")
+	for index := 0; index+5 < len(tokens); index++ {
+		if tokens[index] != "products" || tokens[index+2] != "releases" || tokens[index+4] != "product_files" {
+			continue
+		}
+
+		releaseId, err := strconv.Atoi(tokens[index+3])
+		if err != nil {
+			return Key{}, false
+		}
+		return Key{
+			ProductSlug: tokens[index+1],
+			ReleaseId:   releaseId,
+			FileId:      productFile.Id,
+			Checksum:    checksum(productFile),
+		}, true
+	}
+	return Key{}, false
+}
+
+func checksum(productFile *resource.ProductFile) string {
+	if productFile.Sha256 != "" {
+		return "sha256-" + productFile.Sha256
+	}
+	if productFile.Md5 != "" {
+		return "md5-" + productFile.Md5
+	}
+	return noChecksum
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%s/%d/%d/%s", k.ProductSlug, k.ReleaseId, k.FileId, k.Checksum)
+}
+
+// Entry is a file stored in the cache. LastUsed is updated every time the
+// entry is fetched, so that pruning drops the least recently used files.
+type Entry struct {
+	Key      Key
+	Name     string
+	Path     string
+	Size     int64
+	LastUsed time.Time
+}
+
+type Cache struct {
+	Dir string
+}
+
+func New(dir string) *Cache {
+	return &Cache{Dir: dir}
+}
+
+func (c *Cache) entryDir(key Key) string {
+	return filepath.Join(c.Dir, key.ProductSlug, strconv.Itoa(key.ReleaseId), strconv.Itoa(key.FileId), key.Checksum)
+}
+
+// Fetch copies the cached file for key to fileName, after verifying it
+// against the checksum of key. It returns false if the file isn't cached; an
+// entry that fails verification is removed.
+func (c *Cache) Fetch(key Key, fileName string) (bool, error) {
+	if key.Checksum == noChecksum {
+		return false, nil
+	}
+
+	entry, err := c.lookup(key)
+	if err != nil || entry == "" {
+		return false, err
+	}
+
+	h, expected, err := keyHash(key)
+	if err != nil {
+		return false, err
+	}
+	err = copyFile(entry, fileName, h, expected)
+	if errors.Is(err, errCorrupt) {
+		os.Remove(entry)
+		removeEmptyParents(filepath.Dir(entry), c.Dir)
+		return false, fmt.Errorf("%s: %w", key, err)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	os.Chtimes(entry, now, now)
+	return true, nil
+}
+
+func keyHash(key Key) (hash.Hash, string, error) {
+	switch {
+	case strings.HasPrefix(key.Checksum, "sha256-"):
+		return sha256.New(), strings.TrimPrefix(key.Checksum, "sha256-"), nil
+	case strings.HasPrefix(key.Checksum, "md5-"):
+		return md5.New(), strings.TrimPrefix(key.Checksum, "md5-"), nil
+	}
+	return nil, "", fmt.Errorf("Unknown checksum %s", key.Checksum)
+}
+
+func (c *Cache) lookup(key Key) (string, error) {
+	infos, err := ioutil.ReadDir(c.entryDir(key))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	for _, info := range infos {
+		if info.Mode().IsRegular() && !strings.HasPrefix(info.Name(), ".") {
+			return filepath.Join(c.entryDir(key), info.Name()), nil
+		}
+	}
+	return "", nil
+}
+
+// Store adds a copy of fileName to the cache under key. Files without a
+// checksum aren't stored.
+func (c *Cache) Store(key Key, fileName string) error {
+	if key.Checksum == noChecksum {
+		return nil
+	}
+
+	dir := c.entryDir(key)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	return copyFile(fileName, filepath.Join(dir, filepath.Base(fileName)), nil, "")
+}
+
+// List returns every entry of the cache, least recently used first.
+func (c *Cache) List() ([]Entry, error) {
+	entries := []Entry{}
+	err := filepath.Walk(c.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == c.Dir {
+				return nil
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+
+		key, ok := c.keyOf(path)
+		if !ok {
+			return nil
+		}
+		entries = append(entries, Entry{
+			Key:      key,
+			Name:     info.Name(),
+			Path:     path,
+			Size:     info.Size(),
+			LastUsed: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastUsed.Before(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+func (c *Cache) keyOf(path string) (Key, bool) {
+	rel, err := filepath.Rel(c.Dir, path)
+	if err != nil {
+		return Key{}, false
+	}
+
+	tokens := strings.Split(filepath.ToSlash(rel), "/")
+	if len(tokens) != 5 {
+		return Key{}, false
+	}
+
+	releaseId, err := strconv.Atoi(tokens[1])
+	if err != nil {
+		return Key{}, false
+	}
+	fileId, err := strconv.Atoi(tokens[2])
+	if err != nil {
+		return Key{}, false
+	}
+	return Key{ProductSlug: tokens[0], ReleaseId: releaseId, FileId: fileId, Checksum: tokens[3]}, true
+}
+
+// Prune removes the entries not used for longer than maxAge, then the least
+// recently used entries until the cache holds at most maxSize bytes. Zero
+// disables either limit. It returns the removed entries.
+func (c *Cache) Prune(maxSize int64, maxAge time.Duration) ([]Entry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+
+	removed := []Entry{}
+	for _, entry := range entries {
+		expired := maxAge > 0 && time.Since(entry.LastUsed) > maxAge
+		oversized := maxSize > 0 && total > maxSize
+		if !expired && !oversized {
+			continue
+		}
+
+		err = os.Remove(entry.Path)
+		if err != nil {
+			return removed, err
+		}
+		removeEmptyParents(filepath.Dir(entry.Path), c.Dir)
+
+		total -= entry.Size
+		removed = append(removed, entry)
+	}
+	return removed, nil
+}
+
+func removeEmptyParents(dir, root string) {
+	for dir != root && strings.HasPrefix(dir, root) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// copyFile copies src to dest through a temporary file next to it, so dest
+// never holds part of src. With h set, dest is only written if the copy
+// hashes to expected.
+func copyFile(src, dest string, h hash.Hash, expected string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := ioutil.TempFile(filepath.Dir(dest), "."+filepath.Base(dest)+".")
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = out
+	if h != nil {
+		w = io.MultiWriter(out, h)
+	}
+	_, err = io.Copy(w, in)
+	if err == nil {
+		err = out.Chmod(0644)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && h != nil {
+		if actual := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(actual, expected) {
+			err = fmt.Errorf("%w: expected checksum %s, got %s", errCorrupt, expected, actual)
+		}
+	}
+	if err != nil {
+		os.Remove(out.Name())
+		return err
+	}
+	return os.Rename(out.Name(), dest)
+}
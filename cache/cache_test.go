@@ -0,0 +1,239 @@
+package cache_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cfmobile/gopivnet/cache"
+	"github.com/cfmobile/gopivnet/resource"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache", func() {
+	var dir string
+	var c *cache.Cache
+	var key cache.Key
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		c = cache.New(filepath.Join(dir, "cache"))
+		key = cache.Key{ProductSlug: "p-redis", ReleaseId: 123, FileId: 200, Checksum: "sha256-abc"}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	checksum := func(contents string) string {
+		sum := sha256.Sum256([]byte(contents))
+		return "sha256-" + hex.EncodeToString(sum[:])
+	}
+
+	store := func(key cache.Key, name, contents string) {
+		fileName := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(fileName, []byte(contents), 0644)).To(Succeed())
+		Expect(c.Store(key, fileName)).To(Succeed())
+	}
+
+	Context("KeyFor", func() {
+		It("reads the product slug and release id from the download link", func() {
+			productFile := &resource.ProductFile{
+				Id:     200,
+				Sha256: "abc",
+				Links: resource.Links{
+					"download": resource.Link{Url: "https://network.pivotal.io/api/v2/products/p-redis/releases/123/product_files/200/download"},
+				},
+			}
+
+			k, ok := cache.KeyFor(productFile)
+			Expect(ok).To(BeTrue())
+			Expect(k).To(Equal(key))
+		})
+
+		It("falls back to the md5, and to no checksum", func() {
+			productFile := &resource.ProductFile{
+				Id:  200,
+				Md5: "def",
+				Links: resource.Links{
+					"download": resource.Link{Url: "/api/v2/products/p-redis/releases/123/product_files/200/download"},
+				},
+			}
+
+			k, _ := cache.KeyFor(productFile)
+			Expect(k.Checksum).To(Equal("md5-def"))
+
+			productFile.Md5 = ""
+			k, _ = cache.KeyFor(productFile)
+			Expect(k.Checksum).To(Equal("none"))
+		})
+
+		It("returns false without a recognizable download link", func() {
+			_, ok := cache.KeyFor(&resource.ProductFile{Id: 200})
+			Expect(ok).To(BeFalse())
+
+			_, ok = cache.KeyFor(&resource.ProductFile{
+				Id:    200,
+				Links: resource.Links{"download": resource.Link{Url: "https://example.com/file"}},
+			})
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("Fetch", func() {
+		BeforeEach(func() {
+			key.Checksum = checksum("tile")
+		})
+
+		It("returns false if the file isn't cached", func() {
+			found, err := c.Fetch(key, filepath.Join(dir, "out"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+			Expect(filepath.Join(dir, "out")).ToNot(BeAnExistingFile())
+		})
+
+		It("places a stored file at the destination", func() {
+			store(key, "redis.pivotal", "tile")
+
+			found, err := c.Fetch(key, filepath.Join(dir, "out"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			contents, err := ioutil.ReadFile(filepath.Join(dir, "out"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("tile"))
+		})
+
+		It("places a copy that can be changed without changing the cache", func() {
+			store(key, "redis.pivotal", "tile")
+
+			_, err := c.Fetch(key, filepath.Join(dir, "out"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "out"), []byte("edit"), 0644)).To(Succeed())
+
+			found, err := c.Fetch(key, filepath.Join(dir, "again"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			contents, err := ioutil.ReadFile(filepath.Join(dir, "again"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("tile"))
+		})
+
+		It("removes an entry that doesn't match its checksum", func() {
+			store(key, "redis.pivotal", "tile")
+			entries, err := c.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ioutil.WriteFile(entries[0].Path, []byte("bad!"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "out"), []byte("old"), 0644)).To(Succeed())
+
+			found, err := c.Fetch(key, filepath.Join(dir, "out"))
+			Expect(err).To(HaveOccurred())
+			Expect(found).To(BeFalse())
+
+			contents, err := ioutil.ReadFile(filepath.Join(dir, "out"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("old"))
+			entries, err = c.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+
+		It("doesn't cache files without a checksum", func() {
+			key.Checksum = "none"
+			store(key, "redis.pivotal", "tile")
+
+			found, err := c.Fetch(key, filepath.Join(dir, "out"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+			entries, err := c.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+
+		It("doesn't serve a file stored with another checksum", func() {
+			store(key, "redis.pivotal", "tile")
+
+			key.Checksum = "sha256-new"
+			found, err := c.Fetch(key, filepath.Join(dir, "out"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Context("List and Prune", func() {
+		var old time.Time
+
+		BeforeEach(func() {
+			old = time.Now().Add(-48 * time.Hour)
+
+			key.Checksum = checksum("0123456789")
+			store(key, "redis.pivotal", "0123456789")
+			other := cache.Key{ProductSlug: "p-mysql", ReleaseId: 7, FileId: 8, Checksum: checksum("01234")}
+			store(other, "mysql.pivotal", "01234")
+
+			entries, err := c.List()
+			Expect(err).ToNot(HaveOccurred())
+			for _, entry := range entries {
+				if entry.Key == key {
+					Expect(os.Chtimes(entry.Path, old, old)).To(Succeed())
+				}
+			}
+		})
+
+		It("lists the entries, least recently used first", func() {
+			entries, err := c.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(2))
+
+			Expect(entries[0].Key).To(Equal(key))
+			Expect(entries[0].Name).To(Equal("redis.pivotal"))
+			Expect(entries[0].Size).To(Equal(int64(10)))
+			Expect(entries[1].Key.ProductSlug).To(Equal("p-mysql"))
+		})
+
+		It("lists nothing if the cache directory doesn't exist", func() {
+			entries, err := cache.New(filepath.Join(dir, "missing")).List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+
+		It("removes the entries older than the max age", func() {
+			removed, err := c.Prune(0, 24*time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removed).To(HaveLen(1))
+			Expect(removed[0].Key).To(Equal(key))
+
+			entries, _ := c.List()
+			Expect(entries).To(HaveLen(1))
+			Expect(filepath.Join(dir, "cache", "p-redis")).ToNot(BeADirectory())
+		})
+
+		It("removes the least recently used entries until under the max size", func() {
+			removed, err := c.Prune(12, 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removed).To(HaveLen(1))
+			Expect(removed[0].Key).To(Equal(key))
+
+			removed, err = c.Prune(5, 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removed).To(BeEmpty())
+		})
+
+		It("marks fetched entries as used", func() {
+			_, err := c.Fetch(key, filepath.Join(dir, "out"))
+			Expect(err).ToNot(HaveOccurred())
+
+			removed, err := c.Prune(0, 24*time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removed).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cfmobile/gopivnet/cache"
+)
+
+const cacheDirEnv = "GOPIVNET_CACHE_DIR"
+
+func cacheCommand(args []string) {
+	if len(args) < 1 || (args[0] != "ls" && args[0] != "prune") {
+		usageError("Usage: gopivnet cache ls|prune [flags]")
+	}
+
+	flags := flag.NewFlagSet("cache "+args[0], flag.ExitOnError)
+	dir := flags.String("cache-dir", "", "cache directory. Defaults to the profile's cache_dir or $"+cacheDirEnv)
+	profile := addProfileFlags(flags)
+	output := flags.String("output", "table", "output format: table, json or yaml")
+	maxSize := flags.String("max-size", "", "prune: remove the least recently used files until the cache is at most this big, e.g. '50G'")
+	maxAge := flags.Duration("max-age", 0, "prune: remove the files not used for longer than this, e.g. '720h'")
+	flags.Parse(args[1:])
+	jsonOutput = *output == "json"
+
+	if *dir == "" {
+		*dir = profile.load().CacheDir
+	}
+	if *dir == "" {
+		usageError("Need a cache directory")
+	}
+	if !validOutputFormat(*output) {
+		usageError(fmt.Sprintf("Unknown output format %q, expected one of %s", *output, strings.Join(outputFormats, ", ")))
+	}
+
+	downloadCache := cache.New(*dir)
+
+	var entries []cache.Entry
+	var err error
+	if args[0] == "ls" {
+		entries, err = downloadCache.List()
+	} else {
+		size, sizeErr := parseSize(*maxSize)
+		if sizeErr != nil {
+			usageError(sizeErr.Error())
+		}
+		if size == 0 && *maxAge == 0 {
+			usageError("Need -max-size or -max-age")
+		}
+		entries, err = downloadCache.Prune(size, *maxAge)
+	}
+	if err != nil {
+		fatal(err)
+	}
+
+	err = cacheListing(entries).print(os.Stdout, *output)
+	if err != nil {
+		fatal(err)
+	}
+}
+
+type cacheRow struct {
+	Product   string    `json:"product" yaml:"product"`
+	ReleaseId int       `json:"release_id" yaml:"release_id"`
+	FileId    int       `json:"file_id" yaml:"file_id"`
+	Checksum  string    `json:"checksum" yaml:"checksum"`
+	Name      string    `json:"name" yaml:"name"`
+	Size      int64     `json:"size" yaml:"size"`
+	LastUsed  time.Time `json:"last_used" yaml:"last_used"`
+}
+
+func cacheListing(entries []cache.Entry) listing {
+	l := listing{header: []string{"PRODUCT", "RELEASE", "FILE", "NAME", "SIZE", "LAST USED"}}
+	data := []cacheRow{}
+	for _, entry := range entries {
+		data = append(data, cacheRow{
+			Product:   entry.Key.ProductSlug,
+			ReleaseId: entry.Key.ReleaseId,
+			FileId:    entry.Key.FileId,
+			Checksum:  entry.Key.Checksum,
+			Name:      entry.Name,
+			Size:      entry.Size,
+			LastUsed:  entry.LastUsed,
+		})
+		l.rows = append(l.rows, []string{
+			entry.Key.ProductSlug,
+			strconv.Itoa(entry.Key.ReleaseId),
+			strconv.Itoa(entry.Key.FileId),
+			entry.Name,
+			formatSize(entry.Size),
+			entry.LastUsed.Format("2006-01-02 15:04"),
+		})
+	}
+	l.data = data
+	return l
+}
+
+// parseSize parses a number of bytes with an optional K, M, G or T suffix,
+// in powers of 1024.
+func parseSize(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	number := strings.TrimSuffix(strings.ToUpper(value), "B")
+	multiplier := int64(1)
+	if index := strings.IndexAny(number, "KMGT"); index == len(number)-1 && index > 0 {
+		multiplier = int64(1) << (10 * uint(strings.IndexByte("KMGT", number[index])+1))
+		number = number[:index]
+	}
+
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("Invalid size %q, expected a number of bytes such as '500M' or '50G'", value)
+	}
+	return size * multiplier, nil
+}
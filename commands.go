@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cfmobile/gopivnet/api"
+	"github.com/cfmobile/gopivnet/resource"
+)
+
+// commands are run with the arguments following their name. Without a
+// command gopivnet downloads a product file.
+var commands = map[string]func(args []string){
+	"products": productsCommand,
+	"releases": releasesCommand,
+	"files":    filesCommand,
+	"check":    checkCommand,
+	"in":       inCommand,
+	"out":      outCommand,
+	"cache":    cacheCommand,
+	"serve":    serveCommand,
+
+	"create-release": createReleaseCommand,
+	"upload-file":    uploadFileCommand,
+	"plan-upgrade":   planUpgradeCommand,
+	"verify":         verifyCommand,
+}
+
+type command struct {
+	flags     *flag.FlagSet
+	token     *string
+	output    *string
+	json      *bool
+	transport *transportFlags
+	profile   *profileFlags
+}
+
+func newCommand(name string) *command {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	return &command{
+		flags:     flags,
+		token:     flags.String("token", "", "pivnet api token or UAA refresh token"),
+		output:    flags.String("output", "table", "output format: table, json or yaml"),
+		json:      flags.Bool("json", false, "same as -output json, which also prints errors as JSON objects"),
+		transport: addTransportFlags(flags),
+		profile:   addProfileFlags(flags),
+	}
+}
+
+func (c *command) parse(args []string) (api.Api, context.Context, context.CancelFunc) {
+	c.parseFlags(args)
+	return c.newApi()
+}
+
+// newApi builds the api from the parsed flags.
+func (c *command) newApi() (api.Api, context.Context, context.CancelFunc) {
+	ctx, cancel := cancelOnSignal(0)
+	profile := c.profile.load()
+	options := append([]api.Option{api.WithEndpoint(profile.Url)}, c.transport.options(profile)...)
+	return api.New(pivnetToken(*c.token, profile), options...), ctx, cancel
+}
+
+// parseFlags parses the flags alone, for commands that may not need the api.
+func (c *command) parseFlags(args []string) {
+	c.flags.Parse(args)
+	if *c.json {
+		*c.output = "json"
+	}
+	jsonOutput = *c.output == "json"
+
+	if !validOutputFormat(*c.output) {
+		usageError(fmt.Sprintf("Unknown output format %q, expected one of %s", *c.output, strings.Join(outputFormats, ", ")))
+	}
+}
+
+func (c *command) print(l listing) {
+	err := l.print(os.Stdout, *c.output)
+	if err != nil {
+		fatal(err)
+	}
+}
+
+type productRow struct {
+	Id   int    `json:"id" yaml:"id"`
+	Slug string `json:"slug" yaml:"slug"`
+	Name string `json:"name" yaml:"name"`
+}
+
+func productsCommand(args []string) {
+	cmd := newCommand("products")
+	search := cmd.flags.String("search", "", "only list products whose slug or name resembles this text, best matches first")
+	pivnetApi, ctx, cancel := cmd.parse(args)
+	defer cancel()
+
+	products, err := pivnetApi.SearchProductsContext(ctx, *search)
+	if err != nil {
+		fatal(err)
+	}
+
+	l := listing{header: []string{"SLUG", "NAME", "ID"}}
+	data := []productRow{}
+	for _, product := range products {
+		data = append(data, productRow{Id: product.Id, Slug: product.Slug, Name: product.Name})
+		l.rows = append(l.rows, []string{product.Slug, product.Name, strconv.Itoa(product.Id)})
+	}
+	l.data = data
+	cmd.print(l)
+}
+
+type releaseRow struct {
+	Id           int    `json:"id" yaml:"id"`
+	Version      string `json:"version" yaml:"version"`
+	ReleaseType  string `json:"release_type" yaml:"release_type"`
+	ReleaseDate  string `json:"release_date" yaml:"release_date"`
+	Availability string `json:"availability" yaml:"availability"`
+	Eula         string `json:"eula" yaml:"eula"`
+}
+
+func releasesCommand(args []string) {
+	cmd := newCommand("releases")
+	product := cmd.flags.String("product", "", "product whose releases are listed")
+	pivnetApi, ctx, cancel := cmd.parse(args)
+	defer cancel()
+
+	if *product == "" {
+		usageError("Need a product name")
+	}
+
+	releases, err := pivnetApi.GetReleasesContext(ctx, *product, api.ReleaseFilter{})
+	if err != nil {
+		fatal(err)
+	}
+
+	l := listing{header: []string{"VERSION", "TYPE", "DATE", "AVAILABILITY", "EULA"}}
+	data := []releaseRow{}
+	for _, release := range releases {
+		data = append(data, releaseRow{
+			Id:           release.Id,
+			Version:      release.Version,
+			ReleaseType:  release.ReleaseType,
+			ReleaseDate:  release.ReleaseDate,
+			Availability: release.Availability,
+			Eula:         release.Eula.Slug,
+		})
+		l.rows = append(l.rows, []string{release.Version, release.ReleaseType, release.ReleaseDate, release.Availability, release.Eula.Slug})
+	}
+	l.data = data
+	cmd.print(l)
+}
+
+type fileRow struct {
+	Id          int    `json:"id" yaml:"id"`
+	Name        string `json:"name" yaml:"name"`
+	File        string `json:"file" yaml:"file"`
+	FileVersion string `json:"file_version" yaml:"file_version"`
+	Size        int64  `json:"size" yaml:"size"`
+	Sha256      string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+	Md5         string `json:"md5,omitempty" yaml:"md5,omitempty"`
+}
+
+func filesCommand(args []string) {
+	cmd := newCommand("files")
+	product := cmd.flags.String("product", "", "product whose files are listed")
+	version := cmd.flags.String("version", "", "version of the product, or a constraint. Defaults to the latest version")
+	pivnetApi, ctx, cancel := cmd.parse(args)
+	defer cancel()
+
+	if *product == "" {
+		usageError("Need a product name")
+	}
+
+	productFiles, err := pivnetApi.GetProductFilesForVersionContext(ctx, *product, *version)
+	if err != nil {
+		fatal(err)
+	}
+
+	l := listing{header: []string{"ID", "NAME", "FILE", "VERSION", "SIZE", "CHECKSUM"}}
+	data := []fileRow{}
+	for _, productFile := range productFiles.Files {
+		data = append(data, fileRow{
+			Id:          productFile.Id,
+			Name:        productFile.DisplayName,
+			File:        productFile.Name(),
+			FileVersion: productFile.FileVersion,
+			Size:        productFile.Size,
+			Sha256:      productFile.Sha256,
+			Md5:         productFile.Md5,
+		})
+		l.rows = append(l.rows, []string{
+			strconv.Itoa(productFile.Id),
+			productFile.DisplayName,
+			productFile.Name(),
+			productFile.FileVersion,
+			formatSize(productFile.Size),
+			checksum(productFile),
+		})
+	}
+	l.data = data
+	cmd.print(l)
+}
+
+func checksum(productFile resource.ProductFile) string {
+	if productFile.Sha256 != "" {
+		return "sha256:" + productFile.Sha256
+	}
+	if productFile.Md5 != "" {
+		return "md5:" + productFile.Md5
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package concourse
+
+import (
+	"context"
+
+	"github.com/cfmobile/gopivnet/api"
+)
+
+// Check returns the releases published since request.Version, including it,
+// oldest first. Without a version, or if it no longer exists, only the latest
+// release is returned.
+func Check(ctx context.Context, pivnetApi api.Api, request CheckRequest) (CheckResponse, error) {
+	err := request.Source.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	releases, err := request.Source.releases(ctx, pivnetApi)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := CheckResponse{}
+	if len(releases) == 0 {
+		return versions, nil
+	}
+
+	from := len(releases) - 1
+	if request.Version != nil {
+		for index, release := range releases {
+			if request.Version.matches(release) {
+				from = index
+				break
+			}
+		}
+	}
+
+	for _, release := range releases[from:] {
+		versions = append(versions, versionOf(release))
+	}
+	return versions, nil
+}
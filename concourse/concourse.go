@@ -0,0 +1,137 @@
+// Package concourse implements the check, in and out steps of a Concourse
+// resource type for Pivotal Network releases.
+package concourse
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+
+	"github.com/cfmobile/gopivnet/api"
+	"github.com/cfmobile/gopivnet/resource"
+)
+
+type Source struct {
+	ApiToken    string `json:"api_token"`
+	ProductSlug string `json:"product_slug"`
+	// ProductVersion is a regular expression the release versions must match.
+	ProductVersion string   `json:"product_version"`
+	ReleaseTypes   []string `json:"release_types"`
+	// Globs select the files fetched by in. Every file is fetched without
+	// globs.
+	Globs []string `json:"globs"`
+	// Endpoint is the url of the api, for a mirror of Pivotal Network.
+	Endpoint string `json:"endpoint"`
+}
+
+// Version identifies a release. Concourse requires every value to be a
+// string.
+type Version struct {
+	ProductVersion string `json:"product_version"`
+	ReleaseId      string `json:"release_id,omitempty"`
+}
+
+type MetadataPair struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type CheckRequest struct {
+	Source  Source   `json:"source"`
+	Version *Version `json:"version"`
+}
+
+type CheckResponse []Version
+
+type InParams struct {
+	// Globs replace the globs of the source.
+	Globs []string `json:"globs"`
+}
+
+type InRequest struct {
+	Source  Source   `json:"source"`
+	Version Version  `json:"version"`
+	Params  InParams `json:"params"`
+}
+
+type InResponse struct {
+	Version  Version        `json:"version"`
+	Metadata []MetadataPair `json:"metadata"`
+}
+
+type OutParams struct {
+	// VersionFile is the path, relative to the sources directory, of a file
+	// holding the version or version constraint of the release to emit.
+	VersionFile string `json:"version_file"`
+}
+
+type OutRequest struct {
+	Source Source    `json:"source"`
+	Params OutParams `json:"params"`
+}
+
+type OutResponse InResponse
+
+func (s Source) validate() error {
+	if s.ProductSlug == "" {
+		return errors.New("Must specify source.product_slug")
+	}
+	_, err := regexp.Compile(s.ProductVersion)
+	return err
+}
+
+// releases returns the releases of the source in the order they were
+// published, oldest first as Concourse expects.
+func (s Source) releases(ctx context.Context, pivnetApi api.Api) ([]resource.Release, error) {
+	versionRegex, err := regexp.Compile(s.ProductVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	releases, err := pivnetApi.GetReleasesContext(ctx, s.ProductSlug, api.ReleaseFilter{ReleaseTypes: s.ReleaseTypes})
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []resource.Release{}
+	for index := len(releases) - 1; index >= 0; index-- {
+		if versionRegex.MatchString(releases[index].Version) {
+			matching = append(matching, releases[index])
+		}
+	}
+	return matching, nil
+}
+
+func versionOf(release resource.Release) Version {
+	return Version{
+		ProductVersion: release.Version,
+		ReleaseId:      strconv.Itoa(release.Id),
+	}
+}
+
+func (v Version) matches(release resource.Release) bool {
+	if v.ReleaseId != "" {
+		return v.ReleaseId == strconv.Itoa(release.Id)
+	}
+	return v.ProductVersion == release.Version
+}
+
+func metadata(release resource.Release) []MetadataPair {
+	pairs := []MetadataPair{
+		{Name: "version", Value: release.Version},
+		{Name: "release_type", Value: release.ReleaseType},
+		{Name: "release_date", Value: release.ReleaseDate},
+		{Name: "availability", Value: release.Availability},
+	}
+	if release.Description != "" {
+		pairs = append(pairs, MetadataPair{Name: "description", Value: release.Description})
+	}
+	if release.ReleaseNotesUrl != "" {
+		pairs = append(pairs, MetadataPair{Name: "release_notes_url", Value: release.ReleaseNotesUrl})
+	}
+	if release.Eula.Slug != "" {
+		pairs = append(pairs, MetadataPair{Name: "eula", Value: release.Eula.Slug})
+	}
+	return pairs
+}
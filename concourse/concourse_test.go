@@ -0,0 +1,241 @@
+package concourse_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	pivnetapi "github.com/cfmobile/gopivnet/api"
+	"github.com/cfmobile/gopivnet/concourse"
+	"github.com/cfmobile/gopivnet/resource"
+	"github.com/cfmobile/gopivnet/resource/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Concourse", func() {
+	var api *pivnetapi.PivnetApi
+	var requester *fakes.FakeReleaseRequester
+	var source concourse.Source
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		requester = new(fakes.FakeReleaseRequester)
+		requester.GetProductContextReturns(&resource.Product{
+			Releases: []resource.Release{
+				resource.Release{Id: 4, Version: "1.9.0", ReleaseType: "Minor Release", Eula: resource.Eula{Slug: "pivotal-eula"}},
+				resource.Release{Id: 3, Version: "1.8.2", ReleaseType: "Security Release"},
+				resource.Release{Id: 2, Version: "1.8.1", ReleaseType: "Maintenance Release"},
+				resource.Release{Id: 1, Version: "1.7.0", ReleaseType: "Minor Release"},
+			},
+		}, nil)
+
+		api = &pivnetapi.PivnetApi{
+			Requester: requester,
+		}
+
+		source = concourse.Source{
+			ApiToken:    "token",
+			ProductSlug: "p-redis",
+		}
+	})
+
+	Context("Check", func() {
+		It("returns the latest release without a version", func() {
+			versions, err := concourse.Check(ctx, api, concourse.CheckRequest{Source: source})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions).To(Equal(concourse.CheckResponse{
+				{ProductVersion: "1.9.0", ReleaseId: "4"},
+			}))
+
+			_, productName := requester.GetProductContextArgsForCall(0)
+			Expect(productName).To(Equal("p-redis"))
+		})
+
+		It("returns the releases since the current version, oldest first", func() {
+			versions, err := concourse.Check(ctx, api, concourse.CheckRequest{
+				Source:  source,
+				Version: &concourse.Version{ProductVersion: "1.8.1", ReleaseId: "2"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions).To(Equal(concourse.CheckResponse{
+				{ProductVersion: "1.8.1", ReleaseId: "2"},
+				{ProductVersion: "1.8.2", ReleaseId: "3"},
+				{ProductVersion: "1.9.0", ReleaseId: "4"},
+			}))
+		})
+
+		It("returns the latest release if the current version is gone", func() {
+			versions, err := concourse.Check(ctx, api, concourse.CheckRequest{
+				Source:  source,
+				Version: &concourse.Version{ProductVersion: "1.6.0", ReleaseId: "0"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions).To(HaveLen(1))
+			Expect(versions[0].ProductVersion).To(Equal("1.9.0"))
+		})
+
+		It("only returns releases matching the version regex and release types", func() {
+			source.ProductVersion = `^1\.8\.`
+			source.ReleaseTypes = []string{"security", "minor"}
+
+			versions, err := concourse.Check(ctx, api, concourse.CheckRequest{
+				Source:  source,
+				Version: &concourse.Version{ProductVersion: "1.8.1"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions).To(Equal(concourse.CheckResponse{
+				{ProductVersion: "1.8.2", ReleaseId: "3"},
+			}))
+		})
+
+		It("returns no versions if no release matches", func() {
+			source.ProductVersion = `^2\.`
+
+			versions, err := concourse.Check(ctx, api, concourse.CheckRequest{Source: source})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions).To(BeEmpty())
+		})
+
+		It("returns an error without a product slug", func() {
+			source.ProductSlug = ""
+
+			_, err := concourse.Check(ctx, api, concourse.CheckRequest{Source: source})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns an error if the version regex is invalid", func() {
+			source.ProductVersion = "("
+
+			_, err := concourse.Check(ctx, api, concourse.CheckRequest{Source: source})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns an error if fetching the releases fails", func() {
+			requester.GetProductContextReturns(nil, errors.New("err"))
+
+			_, err := concourse.Check(ctx, api, concourse.CheckRequest{Source: source})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("In", func() {
+		var server *ghttp.Server
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			server = ghttp.NewServer()
+			server.AllowUnhandledRequests = true
+			server.RouteToHandler("GET", "/", ghttp.RespondWith(http.StatusOK, `aaa`))
+
+			requester.GetProductFilesContextReturns(&resource.ProductFiles{
+				Files: []resource.ProductFile{
+					resource.ProductFile{Id: 1, AwsObjectKey: "product/p-redis-1.9.0.pivotal"},
+					resource.ProductFile{Id: 2, AwsObjectKey: "product/notes.pdf"},
+				},
+			}, nil)
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+			server.Close()
+		})
+
+		It("downloads the files matching the globs with the version and metadata", func() {
+			source.Globs = []string{"*.pivotal"}
+
+			response, err := concourse.In(ctx, api, dir, concourse.InRequest{
+				Source:  source,
+				Version: concourse.Version{ProductVersion: "1.9.0", ReleaseId: "4"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(response.Version).To(Equal(concourse.Version{ProductVersion: "1.9.0", ReleaseId: "4"}))
+			Expect(response.Metadata).To(ContainElement(concourse.MetadataPair{Name: "eula", Value: "pivotal-eula"}))
+
+			_, release := requester.GetProductFilesContextArgsForCall(0)
+			Expect(release.Id).To(Equal(4))
+			Expect(filepath.Join(dir, "p-redis-1.9.0.pivotal")).To(BeAnExistingFile())
+			Expect(filepath.Join(dir, "notes.pdf")).ToNot(BeAnExistingFile())
+
+			version, err := ioutil.ReadFile(filepath.Join(dir, "version"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(version)).To(Equal("1.9.0"))
+			Expect(filepath.Join(dir, "metadata.json")).To(BeAnExistingFile())
+		})
+
+		It("downloads every file without globs, and params override the source globs", func() {
+			source.Globs = []string{"*.pivotal"}
+
+			_, err := concourse.In(ctx, api, dir, concourse.InRequest{
+				Source:  source,
+				Version: concourse.Version{ProductVersion: "1.9.0"},
+				Params:  concourse.InParams{Globs: []string{"*"}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requester.GetProductDownloadUrlContextCallCount()).To(Equal(2))
+			Expect(filepath.Join(dir, "notes.pdf")).To(BeAnExistingFile())
+		})
+
+		It("returns a not found error if the release doesn't exist", func() {
+			_, err := concourse.In(ctx, api, dir, concourse.InRequest{
+				Source:  source,
+				Version: concourse.Version{ProductVersion: "2.0.0", ReleaseId: "9"},
+			})
+			Expect(errors.Is(err, resource.ErrNotFound)).To(BeTrue())
+		})
+	})
+
+	Context("Out", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("emits the release named in the version file", func() {
+			Expect(ioutil.WriteFile(filepath.Join(dir, "version"), []byte("~> 1.8.0\n"), 0644)).To(Succeed())
+
+			response, err := concourse.Out(ctx, api, dir, concourse.OutRequest{
+				Source: source,
+				Params: concourse.OutParams{VersionFile: "version"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Version).To(Equal(concourse.Version{ProductVersion: "1.8.2", ReleaseId: "3"}))
+		})
+
+		It("returns an error without a version file", func() {
+			_, err := concourse.Out(ctx, api, dir, concourse.OutRequest{Source: source})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns an error if the release doesn't match the version regex", func() {
+			Expect(ioutil.WriteFile(filepath.Join(dir, "version"), []byte("1.7.0"), 0644)).To(Succeed())
+			source.ProductVersion = `^1\.8\.`
+
+			_, err := concourse.Out(ctx, api, dir, concourse.OutRequest{
+				Source: source,
+				Params: concourse.OutParams{VersionFile: "version"},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,87 @@
+package concourse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cfmobile/gopivnet/api"
+	"github.com/cfmobile/gopivnet/resource"
+)
+
+// In downloads the files of the release matching the globs into destDir,
+// along with a "version" file holding the release version and a
+// "metadata.json" file describing the release.
+func In(ctx context.Context, pivnetApi api.Api, destDir string, request InRequest) (InResponse, error) {
+	err := request.Source.validate()
+	if err != nil {
+		return InResponse{}, err
+	}
+
+	release, err := findRelease(ctx, pivnetApi, request.Source, request.Version)
+	if err != nil {
+		return InResponse{}, err
+	}
+
+	productFiles, err := pivnetApi.GetProductFilesForVersionContext(ctx, request.Source.ProductSlug, release.Version)
+	if err != nil {
+		return InResponse{}, err
+	}
+
+	files := productFiles.Files
+	globs := request.Params.Globs
+	if globs == nil {
+		globs = request.Source.Globs
+	}
+	if len(globs) > 0 {
+		files, err = api.SelectGlobs(productFiles, "", globs)
+		if err != nil {
+			return InResponse{}, err
+		}
+	}
+
+	err = os.MkdirAll(destDir, 0755)
+	if err != nil {
+		return InResponse{}, err
+	}
+
+	for index := range files {
+		err = pivnetApi.DownloadContext(ctx, &files[index], filepath.Join(destDir, files[index].Name()), api.DownloadOptions{})
+		if err != nil {
+			return InResponse{}, err
+		}
+	}
+
+	err = ioutil.WriteFile(filepath.Join(destDir, "version"), []byte(release.Version), 0644)
+	if err != nil {
+		return InResponse{}, err
+	}
+
+	releaseJson, _ := json.MarshalIndent(release, "", "  ")
+	err = ioutil.WriteFile(filepath.Join(destDir, "metadata.json"), releaseJson, 0644)
+	if err != nil {
+		return InResponse{}, err
+	}
+
+	return InResponse{
+		Version:  versionOf(*release),
+		Metadata: metadata(*release),
+	}, nil
+}
+
+func findRelease(ctx context.Context, pivnetApi api.Api, source Source, version Version) (*resource.Release, error) {
+	releases, err := pivnetApi.GetReleasesContext(ctx, source.ProductSlug, api.ReleaseFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	for index := range releases {
+		if version.matches(releases[index]) {
+			return &releases[index], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: release %s of %s", resource.ErrNotFound, version.ProductVersion, source.ProductSlug)
+}
@@ -0,0 +1,47 @@
+package concourse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/cfmobile/gopivnet/api"
+)
+
+// Out emits the release named by the version file in params, resolved like
+// api.ResolveVersion, so that a pipeline can pin or promote a release it
+// already knows about.
+func Out(ctx context.Context, pivnetApi api.Api, sourcesDir string, request OutRequest) (OutResponse, error) {
+	err := request.Source.validate()
+	if err != nil {
+		return OutResponse{}, err
+	}
+
+	if request.Params.VersionFile == "" {
+		return OutResponse{}, errors.New("Must specify params.version_file")
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(sourcesDir, request.Params.VersionFile))
+	if err != nil {
+		return OutResponse{}, err
+	}
+
+	release, err := pivnetApi.ResolveVersionContext(ctx, request.Source.ProductSlug, strings.TrimSpace(string(contents)))
+	if err != nil {
+		return OutResponse{}, err
+	}
+
+	versionRegex := regexp.MustCompile(request.Source.ProductVersion)
+	if !versionRegex.MatchString(release.Version) {
+		return OutResponse{}, fmt.Errorf("Release %s doesn't match source.product_version %q", release.Version, request.Source.ProductVersion)
+	}
+
+	return OutResponse{
+		Version:  versionOf(*release),
+		Metadata: metadata(*release),
+	}, nil
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// FileName is the name of the config file in the home directory.
+const FileName = ".gopivnet.yml"
+
+// Config holds named profiles of settings, read from YAML:
+//
+//	default_profile: prod
+//	profiles:
+//	  prod:
+//	    token: ...
+//	    cache_dir: /var/cache/gopivnet
+//	  local-mirror:
+//	    url: https://pivnet-mirror.example.com
+//	    token: ...
+//	    proxy: http://proxy.example.com:3128
+//	    ca_cert: /etc/ssl/mirror-ca.pem
+type Config struct {
+	DefaultProfile string             `yaml:"default_profile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+// Profile holds the settings used unless a flag overrides them. Empty fields
+// fall back to the defaults of gopivnet.
+type Profile struct {
+	Url                string `yaml:"url"`
+	Token              string `yaml:"token"`
+	FileType           string `yaml:"file_type"`
+	CacheDir           string `yaml:"cache_dir"`
+	Proxy              string `yaml:"proxy"`
+	CACert             string `yaml:"ca_cert"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// Environment variables override the settings of the profile.
+var environment = map[string]func(p *Profile, value string){
+	"GOPIVNET_URL":       func(p *Profile, value string) { p.Url = value },
+	"PIVNET_TOKEN":       func(p *Profile, value string) { p.Token = value },
+	"GOPIVNET_FILE_TYPE": func(p *Profile, value string) { p.FileType = value },
+	"GOPIVNET_CACHE_DIR": func(p *Profile, value string) { p.CacheDir = value },
+	"GOPIVNET_PROXY":     func(p *Profile, value string) { p.Proxy = value },
+	"GOPIVNET_CA_CERT":   func(p *Profile, value string) { p.CACert = value },
+}
+
+// DefaultPath returns ~/.gopivnet.yml, or "" if there is no home directory.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, FileName)
+}
+
+func Load(fileName string) (*Config, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Config, error) {
+	c := Config{}
+	err := yaml.UnmarshalStrict(data, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.DefaultProfile != "" {
+		if _, ok := c.Profiles[c.DefaultProfile]; !ok {
+			return nil, fmt.Errorf("Default profile \"%s\" is not defined", c.DefaultProfile)
+		}
+	}
+	return &c, nil
+}
+
+// Profile returns the profile called name, or the default profile if name is
+// empty. Without a default_profile, the profile called "default" is the
+// default; if there is none either the empty profile is returned.
+func (c *Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		return c.Profiles["default"], nil
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("Unknown profile \"%s\", expected one of %v", name, c.ProfileNames())
+	}
+	return profile, nil
+}
+
+func (c *Config) ProfileNames() []string {
+	names := []string{}
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithEnv returns the profile with the settings found by lookup, usually
+// os.LookupEnv, replacing its own.
+func (p Profile) WithEnv(lookup func(string) (string, bool)) Profile {
+	for name, set := range environment {
+		if value, ok := lookup(name); ok && value != "" {
+			set(&p, value)
+		}
+	}
+	return p
+}
@@ -0,0 +1,121 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cfmobile/gopivnet/config"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config", func() {
+	configYaml := `
+default_profile: prod
+profiles:
+  prod:
+    token: prod-token
+    cache_dir: /var/cache/gopivnet
+  local-mirror:
+    url: https://pivnet-mirror.example.com
+    token: mirror-token
+    file_type: zip
+    proxy: http://proxy.example.com:3128
+    ca_cert: /etc/ssl/mirror-ca.pem
+    insecure_skip_verify: true
+`
+
+	It("parses the profiles", func() {
+		c, err := config.Parse([]byte(configYaml))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.ProfileNames()).To(Equal([]string{"local-mirror", "prod"}))
+
+		profile, err := c.Profile("local-mirror")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(profile).To(Equal(config.Profile{
+			Url:                "https://pivnet-mirror.example.com",
+			Token:              "mirror-token",
+			FileType:           "zip",
+			Proxy:              "http://proxy.example.com:3128",
+			CACert:             "/etc/ssl/mirror-ca.pem",
+			InsecureSkipVerify: true,
+		}))
+	})
+
+	It("returns the default profile when no name is given", func() {
+		c, err := config.Parse([]byte(configYaml))
+		Expect(err).ToNot(HaveOccurred())
+
+		profile, err := c.Profile("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(profile.Token).To(Equal("prod-token"))
+	})
+
+	It("falls back to the profile called default", func() {
+		c, err := config.Parse([]byte("profiles:\n  default:\n    token: default-token\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		profile, err := c.Profile("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(profile.Token).To(Equal("default-token"))
+	})
+
+	It("returns an empty profile without profiles", func() {
+		profile, err := (&config.Config{}).Profile("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(profile).To(Equal(config.Profile{}))
+	})
+
+	It("returns an error for an unknown profile", func() {
+		c, err := config.Parse([]byte(configYaml))
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = c.Profile("staging")
+		Expect(err).To(MatchError(ContainSubstring("staging")))
+	})
+
+	It("returns an error if the default profile isn't defined", func() {
+		_, err := config.Parse([]byte("default_profile: staging\n"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns an error for unknown settings", func() {
+		_, err := config.Parse([]byte("profiles:\n  prod:\n    tokn: typo\n"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("loads the config from a file", func() {
+		dir, err := ioutil.TempDir("", "")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		fileName := filepath.Join(dir, config.FileName)
+		Expect(ioutil.WriteFile(fileName, []byte(configYaml), 0600)).To(Succeed())
+
+		c, err := config.Load(fileName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.DefaultProfile).To(Equal("prod"))
+	})
+
+	It("lets environment variables override the profile", func() {
+		env := map[string]string{
+			"GOPIVNET_URL":       "https://staging.example.com",
+			"PIVNET_TOKEN":       "env-token",
+			"GOPIVNET_CACHE_DIR": "",
+		}
+		lookup := func(name string) (string, bool) {
+			value, ok := env[name]
+			return value, ok
+		}
+
+		profile := config.Profile{Token: "file-token", CacheDir: "/cache", FileType: "zip"}.WithEnv(lookup)
+		Expect(profile).To(Equal(config.Profile{
+			Url:      "https://staging.example.com",
+			Token:    "env-token",
+			CacheDir: "/cache",
+			FileType: "zip",
+		}))
+	})
+})
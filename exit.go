@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+
+	"github.com/cfmobile/gopivnet/api"
+	"github.com/cfmobile/gopivnet/resource"
+)
+
+// Exit codes, so that scripts can tell failures apart without parsing the
+// log output.
+const (
+	exitError          = 1
+	exitUsage          = 2
+	exitUnauthorized   = 3
+	exitNotFound       = 4
+	exitEulaRequired   = 5
+	exitRateLimited    = 6
+	exitChecksum       = 7
+	exitAmbiguousMatch = 8
+	exitServerError    = 9
+	exitReceipt        = 10
+	exitTimeout        = 124
+	exitInterrupted    = 130
+)
+
+func exitCode(err error) int {
+	var apiErr *resource.APIError
+	var checksumErr *api.ChecksumError
+	var ambiguousErr *api.AmbiguousMatchError
+	var noMatchErr *api.NoMatchError
+	var receiptErr *api.ReceiptMismatchError
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case errors.Is(err, context.DeadlineExceeded):
+		return exitTimeout
+	case errors.Is(err, resource.ErrUnauthorized):
+		return exitUnauthorized
+	case errors.Is(err, resource.ErrNotFound), errors.As(err, &noMatchErr):
+		return exitNotFound
+	case errors.Is(err, resource.ErrEulaRequired):
+		return exitEulaRequired
+	case errors.Is(err, resource.ErrRateLimited):
+		return exitRateLimited
+	case errors.As(err, &checksumErr):
+		return exitChecksum
+	case errors.As(err, &ambiguousErr):
+		return exitAmbiguousMatch
+	case errors.As(err, &receiptErr):
+		return exitReceipt
+	case errors.As(err, &apiErr):
+		return exitServerError
+	}
+	return exitError
+}
+
+// warn reports a failure that doesn't stop the command.
+func warn(err error) {
+	log.Print(err)
+}
+
+func fatal(err error) {
+	log.Print(err)
+	exit(exitCode(err), err.Error())
+}
+
+func usageError(msg string) {
+	log.Print(msg)
+	exit(exitUsage, msg)
+}
+
+func exit(code int, msg string) {
+	if jsonOutput {
+		printError(code, msg)
+	}
+	os.Exit(code)
+}
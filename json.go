@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/cfmobile/gopivnet/api"
+	"github.com/cfmobile/gopivnet/resource"
+)
+
+// jsonOutput is set by -json, or -output json, and makes errors print as JSON
+// objects on stdout as well as in the log.
+var jsonOutput bool
+
+// errorCodes name the exit codes. They are part of the JSON output, so they
+// must not change.
+var errorCodes = map[int]string{
+	exitError:          "error",
+	exitUsage:          "usage",
+	exitUnauthorized:   "unauthorized",
+	exitNotFound:       "not_found",
+	exitEulaRequired:   "eula_required",
+	exitRateLimited:    "rate_limited",
+	exitChecksum:       "checksum_mismatch",
+	exitAmbiguousMatch: "ambiguous_match",
+	exitServerError:    "server_error",
+	exitReceipt:        "receipt_mismatch",
+	exitTimeout:        "timeout",
+	exitInterrupted:    "interrupted",
+}
+
+type errorRecord struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code     string `json:"code"`
+	ExitCode int    `json:"exit_code"`
+	Message  string `json:"message"`
+	// Product is set on the errors of the products of a manifest.
+	Product string `json:"product,omitempty"`
+}
+
+type downloadRecord struct {
+	Action          string  `json:"action"`
+	Product         string  `json:"product,omitempty"`
+	ReleaseId       int     `json:"release_id,omitempty"`
+	Version         string  `json:"version,omitempty"`
+	FileId          int     `json:"file_id"`
+	Name            string  `json:"name"`
+	File            string  `json:"file"`
+	Path            string  `json:"path"`
+	Bytes           int64   `json:"bytes"`
+	Checksum        string  `json:"checksum,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Cached          bool    `json:"cached"`
+	EulaAccepted    bool    `json:"eula_accepted"`
+}
+
+type eulaRecord struct {
+	Action   string `json:"action"`
+	Product  string `json:"product"`
+	Version  string `json:"version,omitempty"`
+	EulaSlug string `json:"eula_slug"`
+	EulaName string `json:"eula_name"`
+	Text     string `json:"text"`
+}
+
+// printJSON prints v as a single line of JSON on stdout.
+func printJSON(v interface{}) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		out, _ = json.Marshal(errorRecord{Error: errorDetail{Code: errorCodes[exitError], ExitCode: exitError, Message: err.Error()}})
+	}
+	fmt.Fprintf(os.Stdout, "%s\n", out)
+}
+
+func printError(code int, msg string) {
+	printJSON(errorRecord{Error: errorDetail{Code: errorCodes[code], ExitCode: code, Message: msg}})
+}
+
+func printProductError(product string, err error) {
+	code := exitCode(err)
+	printJSON(errorRecord{Error: errorDetail{Code: errorCodes[code], ExitCode: code, Message: err.Error(), Product: product}})
+}
+
+// eulaRecorder remembers the releases whose eula it accepted through policy,
+// so download records can tell which files needed one.
+type eulaRecorder struct {
+	policy resource.EulaPolicy
+
+	mu       sync.Mutex
+	accepted map[int]bool
+}
+
+func newEulaRecorder(policy resource.EulaPolicy) *eulaRecorder {
+	return &eulaRecorder{policy: policy, accepted: map[int]bool{}}
+}
+
+func (r *eulaRecorder) AcceptEula(ctx context.Context, eula resource.PendingEula) (bool, error) {
+	accepted, err := r.policy.AcceptEula(ctx, eula)
+	if accepted && err == nil {
+		r.mu.Lock()
+		r.accepted[eula.Release.Id] = true
+		r.mu.Unlock()
+	}
+	return accepted, err
+}
+
+func (r *eulaRecorder) acceptedFor(release *resource.Release) bool {
+	if r == nil || release == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.accepted[release.Id]
+}
+
+// reportDownloads prints a downloadRecord for every file written with
+// options, in place of the usual messages. eulas may be nil.
+func reportDownloads(options *api.DownloadOptions, eulas *eulaRecorder) {
+	options.Progress = nil
+	options.Done = func(result api.DownloadResult) {
+		record := downloadRecord{
+			Action:          "download",
+			Product:         result.ProductSlug,
+			Path:            result.FileName,
+			Bytes:           result.Bytes,
+			DurationSeconds: result.Duration.Seconds(),
+			Cached:          result.Cached,
+			EulaAccepted:    eulas.acceptedFor(result.Release),
+		}
+		if result.Release != nil {
+			record.ReleaseId = result.Release.Id
+			record.Version = result.Release.Version
+		}
+		if result.ProductFile != nil {
+			record.FileId = result.ProductFile.Id
+			record.Name = result.ProductFile.DisplayName
+			record.File = result.ProductFile.Name()
+			record.Checksum = checksum(*result.ProductFile)
+		}
+		printJSON(record)
+	}
+}
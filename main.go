@@ -1,55 +1,228 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/cfmobile/gopivnet/api"
+	"github.com/cfmobile/gopivnet/config"
+	"github.com/cfmobile/gopivnet/manifest"
 	"github.com/cfmobile/gopivnet/resource"
 )
 
 var productName = flag.String("product", "", "product to download")
 
-var version = flag.String("version", "", "version of the product. If missing download the latest version")
+var version = flag.String("version", "", "version of the product, or a constraint such as '~> 1.8', '>=1.7.3 <1.9' or '1.8.*'. If missing download the latest version")
 
-var token = flag.String("token", "", "pivnet token")
+var token = flag.String("token", "", "pivnet api token or UAA refresh token")
 
 var file = flag.String("file", "", "filename where to save the pivotal product")
 
-var fileType = flag.String("fileType", "", "type of file.  Defaults to 'pivotal' tile.")
+var fileType = flag.String("fileType", "", "type of file.  Defaults to the profile's file_type or 'pivotal' tile.")
+
+var glob = flag.String("glob", "", "glob matched against the file names of the release, e.g. '*vsphere*.tgz'. Fails if more than one file matches")
+
+var all = flag.Bool("all", false, "download every file of the release into -dir")
+
+var dir = flag.String("dir", ".", "directory where -all and -dependencies save the files they download")
+
+var dependencies = flag.Bool("dependencies", false, "also download the files of the releases the release depends on, such as its stemcell, into -dir")
+
+var dependencyGlobs = flag.String("dependency-glob", "", "comma separated globs selecting the files of the dependencies to download, e.g. '*vsphere*'. Every file by default")
+
+var manifestFile = flag.String("manifest", "", "YAML or JSON manifest listing products to download. Replaces -product, -version, -file and -fileType")
+
+var retries = flag.Int("retries", resource.DefaultRetryPolicy.MaxAttempts, "number of attempts made for each request before giving up")
+
+var parallel = flag.Int("parallel", 1, "number of concurrent connections used to download the file")
+
+var timeout = flag.Duration("timeout", 0, "maximum duration of the whole run, e.g. '2h'. No limit by default")
+
+var requestTimeout = flag.Duration("request-timeout", time.Minute, "maximum duration of a single Pivotal Network api request, not counting downloads")
+
+var releaseTypes = flag.String("release-type", "", "comma separated release types to consider, e.g. 'major,minor'. All types by default")
+
+var gaOnly = flag.Bool("ga-only", false, "ignore alpha, beta and developer releases")
+
+var releasedBefore = flag.String("released-before", "", "only consider releases published before this date, formatted as YYYY-MM-DD")
+
+var releasedAfter = flag.String("released-after", "", "only consider releases published after this date, formatted as YYYY-MM-DD")
+
+var eula = flag.String("eula", "auto", "how to handle eulas that must be accepted before downloading: 'auto' accepts them, 'fail' stops, 'prompt' asks on the terminal, or a comma separated list of eula slugs to accept")
+
+var eulaAuditLog = flag.String("eula-audit-log", "", "file to which every eula acceptance is appended as a line of JSON")
+
+var cacheDir = flag.String("cache-dir", "", "directory where downloaded files are kept and reused by later downloads. Defaults to the profile's cache_dir or $"+cacheDirEnv+", no cache if empty")
+
+var showProgress = flag.Bool("progress", true, "show the progress of downloads, as a bar on a terminal and as log lines otherwise")
+
+var progressInterval = flag.Duration("progress-interval", 0, "how often download progress is shown. Defaults to 200ms on a terminal and 30s otherwise")
+
+var transportOptions = addTransportFlags(flag.CommandLine)
+
+var profileOptions = addProfileFlags(flag.CommandLine)
+
+var writeReceipts = flag.Bool("receipt", true, "write a <file>.pivnet.json receipt recording the origin of every file downloaded, for 'gopivnet verify'")
+
+var jsonFlag = flag.Bool("json", false, "print a line of JSON for every file downloaded, and errors as JSON objects with a stable code, instead of messages")
+
+var showEula = flag.Bool("show-eula", false, "print the eula of the release instead of downloading it")
 
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(os.Args[2:])
+			return
+		}
+	}
+
+	flag.Usage = usage
 	flag.Parse()
+	jsonOutput = *jsonFlag
 
-	if *productName == "" {
-		log.Fatal("Need a product name")
+	if *productName == "" && *manifestFile == "" {
+		usageError("Need a product name")
 	}
 
-	if *token == "" {
-		var env = os.Getenv("PIVNET_TOKEN")
-		if env != "" {
-			token = &env
-		} else {
-			log.Fatal("Need a pivnet token")
-		}
+	profile := profileOptions.load()
+	*token = pivnetToken(*token, profile)
+	if *fileType == "" {
+		*fileType = profile.FileType
+	}
+	if *cacheDir == "" {
+		*cacheDir = profile.CacheDir
+	}
+
+	selector := api.FileSelector{
+		FileType: *fileType,
+		Glob:     *glob,
 	}
 
 	if *fileType == "" {
 		*fileType = "pivotal"
 	}
 
-	pivnetApi := api.New(*token)
+	retryPolicy := resource.DefaultRetryPolicy
+	retryPolicy.MaxAttempts = *retries
+
+	policy := eulaPolicy(*eula)
+	var eulas *eulaRecorder
+	if jsonOutput {
+		eulas = newEulaRecorder(policy)
+		policy = eulas
+	}
+
+	apiOptions := []api.Option{
+		api.WithRetryPolicy(retryPolicy),
+		api.WithRequestTimeout(*requestTimeout),
+		api.WithReleaseFilter(releaseFilter()),
+		api.WithEulaPolicy(policy),
+		api.WithCache(*cacheDir),
+	}
+	apiOptions = append(apiOptions, api.WithEndpoint(profile.Url))
+	apiOptions = append(apiOptions, transportOptions.options(profile)...)
+	if *eulaAuditLog != "" {
+		auditLog, err := os.OpenFile(*eulaAuditLog, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			fatal(err)
+		}
+		defer auditLog.Close()
+		apiOptions = append(apiOptions, api.WithEulaAuditLog(auditLog))
+	}
+
+	pivnetApi := api.New(*token, apiOptions...)
+	downloadOptions := api.DownloadOptions{
+		Parallel: *parallel,
+		Receipt:  *writeReceipts,
+		Warn:     warn,
+	}
+	if jsonOutput {
+		reportDownloads(&downloadOptions, eulas)
+	} else {
+		printDownloads(&downloadOptions)
+		if *showProgress {
+			progressOptions(&downloadOptions, *progressInterval)
+		}
+	}
+
+	ctx, cancel := cancelOnSignal(*timeout)
+	defer cancel()
+
+	if *manifestFile != "" {
+		m, err := manifest.Load(*manifestFile)
+		if err != nil {
+			fatal(err)
+		}
+
+		err = manifest.SyncContext(ctx, pivnetApi, m, downloadOptions)
+		var syncErr *manifest.SyncError
+		if errors.As(err, &syncErr) {
+			for _, failure := range syncErr.Failures {
+				if jsonOutput {
+					printProductError(failure.Product.Name, failure)
+				} else {
+					warn(failure)
+				}
+			}
+		}
+		if err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	if *showEula {
+		releaseEula, err := pivnetApi.GetEulaContext(ctx, *productName, *version)
+		if err != nil {
+			fatal(err)
+		}
+		if jsonOutput {
+			printJSON(eulaRecord{
+				Action:   "show_eula",
+				Product:  *productName,
+				Version:  *version,
+				EulaSlug: releaseEula.Slug,
+				EulaName: releaseEula.Name,
+				Text:     releaseEula.Text(),
+			})
+			return
+		}
+		fmt.Printf("%s (%s)\n\n%s\n", releaseEula.Name, releaseEula.Slug, releaseEula.Text())
+		return
+	}
+
+	if *all {
+		err := pivnetApi.DownloadReleaseContext(ctx, *productName, *version, *dir, downloadOptions)
+		if err != nil {
+			fatal(err)
+		}
+		downloadDependencies(ctx, pivnetApi, downloadOptions)
+		return
+	}
+
+	// Resolve the release once and pick the file by its exact version, so
+	// the file and the release recorded for it can't disagree.
+	release, err := pivnetApi.ResolveVersionContext(ctx, *productName, *version)
+	if err != nil {
+		fatal(err)
+	}
 
 	var pivotalProduct *resource.ProductFile
-	var err error
-	if *version != "" {
-		pivotalProduct, err = pivnetApi.GetProductFileForVersion(*productName, *version, *fileType)
+	if *glob != "" {
+		pivotalProduct, err = pivnetApi.GetProductFileContext(ctx, *productName, release.Version, selector)
 	} else {
-		pivotalProduct, err = pivnetApi.GetLatestProductFile(*productName, *fileType)
+		pivotalProduct, err = pivnetApi.GetProductFileForVersionContext(ctx, *productName, release.Version, *fileType)
 	}
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	fileName := *file
@@ -57,5 +230,112 @@ func main() {
 		fileName = pivotalProduct.Name()
 	}
 
-	pivnetApi.Download(pivotalProduct, fileName)
+	err = pivnetApi.DownloadContext(ctx, pivotalProduct, fileName, downloadOptions.ForRelease(*productName, *release))
+	if err != nil {
+		fatal(err)
+	}
+	downloadDependencies(ctx, pivnetApi, downloadOptions)
+}
+
+func downloadDependencies(ctx context.Context, pivnetApi api.Api, downloadOptions api.DownloadOptions) {
+	if !*dependencies {
+		return
+	}
+
+	globs := []string{}
+	if *dependencyGlobs != "" {
+		globs = strings.Split(*dependencyGlobs, ",")
+	}
+	err := pivnetApi.DownloadDependenciesContext(ctx, *productName, *version, *dir, globs, downloadOptions)
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage of gopivnet:
+  gopivnet [flags]                   download a product file
+  gopivnet products [-search text]   list products
+  gopivnet releases -product slug    list the releases of a product
+  gopivnet files -product slug       list the files of a release
+  gopivnet check|in|out              run as a Concourse resource, see the README
+  gopivnet cache ls|prune            list or prune the download cache
+  gopivnet serve -dir dir            serve downloaded files as a Pivotal Network mirror
+  gopivnet create-release            create a release of a product
+  gopivnet upload-file               upload a file and add it to a release
+  gopivnet plan-upgrade              list the releases to install to upgrade a product
+  gopivnet verify file...            check downloaded files against their receipts
+
+Run "gopivnet <command> -help" for the flags of a command. Download flags:
+`)
+	flag.PrintDefaults()
+}
+
+// pivnetToken returns token, or the token of the profile, which
+// PIVNET_TOKEN overrides, if it is empty.
+func pivnetToken(token string, profile config.Profile) string {
+	if token == "" {
+		token = profile.Token
+	}
+	if token == "" {
+		usageError("Need a pivnet token")
+	}
+	return token
+}
+
+func releaseFilter() api.ReleaseFilter {
+	filter := api.ReleaseFilter{
+		GAOnly:         *gaOnly,
+		ReleasedBefore: parseDate("released-before", *releasedBefore),
+		ReleasedAfter:  parseDate("released-after", *releasedAfter),
+	}
+	if *releaseTypes != "" {
+		filter.ReleaseTypes = strings.Split(*releaseTypes, ",")
+	}
+	return filter
+}
+
+func eulaPolicy(value string) resource.EulaPolicy {
+	switch value {
+	case "auto":
+		return resource.AutoAcceptEula
+	case "fail":
+		return resource.RejectEula
+	case "prompt":
+		return resource.PromptForEula(os.Stdin, os.Stderr)
+	case "":
+		usageError("-eula can't be empty")
+	}
+	return resource.AcceptEulas(strings.Split(value, ",")...)
+}
+
+func parseDate(flagName, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+
+	date, err := time.Parse(api.ReleaseDateFormat, value)
+	if err != nil {
+		usageError(fmt.Sprintf("Invalid -%s date %q, expected YYYY-MM-DD", flagName, value))
+	}
+	return date
+}
+
+// cancelOnSignal returns a context that is cancelled on SIGINT or SIGTERM, or
+// once timeout has elapsed if it is not zero.
+func cancelOnSignal(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Printf("Received %s, stopping", sig)
+		cancel()
+	}()
+
+	return ctx, cancel
 }
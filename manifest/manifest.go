@@ -0,0 +1,62 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Manifest lists the product files to mirror. It is read from YAML, and
+// since JSON is valid YAML a JSON manifest works as well:
+//
+//	products:
+//	- product: elastic-runtime
+//	  version: "~> 1.8"
+//	  globs: ["cf-*.pivotal"]
+//	  destination: tiles
+//	- product: stemcells
+//	  version: "3263.*"
+//	  globs: ["*vsphere*"]
+//	  destination: stemcells
+type Manifest struct {
+	Products []Product `yaml:"products"`
+}
+
+type Product struct {
+	Name        string   `yaml:"product"`
+	Version     string   `yaml:"version"`
+	FileType    string   `yaml:"file_type"`
+	Globs       []string `yaml:"globs"`
+	Destination string   `yaml:"destination"`
+}
+
+func Load(fileName string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Manifest, error) {
+	m := Manifest{}
+	err := yaml.Unmarshal(data, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(m.Products) == 0 {
+		return nil, errors.New("Manifest does not list any products")
+	}
+
+	for index, product := range m.Products {
+		if product.Name == "" {
+			return nil, fmt.Errorf("Product %d in the manifest has no name", index+1)
+		}
+	}
+
+	return &m, nil
+}
@@ -0,0 +1,243 @@
+package manifest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	pivnetapi "github.com/cfmobile/gopivnet/api"
+	"github.com/cfmobile/gopivnet/manifest"
+	"github.com/cfmobile/gopivnet/resource"
+	"github.com/cfmobile/gopivnet/resource/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Manifest", func() {
+	Context("Parse", func() {
+		It("parses a yaml manifest", func() {
+			m, err := manifest.Parse([]byte(`
+products:
+- product: elastic-runtime
+  version: "~> 1.8"
+  globs: ["cf-*.pivotal"]
+  destination: tiles
+- product: p-redis
+  file_type: pivotal
+`))
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(m.Products).To(Equal([]manifest.Product{
+				{Name: "elastic-runtime", Version: "~> 1.8", Globs: []string{"cf-*.pivotal"}, Destination: "tiles"},
+				{Name: "p-redis", FileType: "pivotal"},
+			}))
+		})
+
+		It("parses a json manifest", func() {
+			m, err := manifest.Parse([]byte(`{"products": [{"product": "p-redis", "version": "1.4.7"}]}`))
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(m.Products).To(Equal([]manifest.Product{
+				{Name: "p-redis", Version: "1.4.7"},
+			}))
+		})
+
+		It("returns an error if a product has no name", func() {
+			_, err := manifest.Parse([]byte(`{"products": [{"version": "1.4.7"}]}`))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns an error if there are no products", func() {
+			_, err := manifest.Parse([]byte(`products: []`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Sync", func() {
+		var api *pivnetapi.PivnetApi
+		var requester *fakes.FakeReleaseRequester
+		var server *ghttp.Server
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			server = ghttp.NewServer()
+			server.AllowUnhandledRequests = true
+			server.RouteToHandler("GET", "/", ghttp.RespondWith(http.StatusOK, `aaa`))
+
+			requester = new(fakes.FakeReleaseRequester)
+			requester.GetProductContextReturns(&resource.Product{
+				Releases: []resource.Release{
+					resource.Release{Id: 1, Version: "1.8.3"},
+				},
+			}, nil)
+			requester.GetProductFilesContextReturns(&resource.ProductFiles{
+				Files: []resource.ProductFile{
+					resource.ProductFile{Id: 1, AwsObjectKey: "product/cf-1.8.3.pivotal"},
+					resource.ProductFile{Id: 2, AwsObjectKey: "product/notes.pdf"},
+					resource.ProductFile{Id: 3, AwsObjectKey: "product/license.txt"},
+				},
+			}, nil)
+			requester.GetProductDownloadUrlContextReturns(server.URL(), nil)
+
+			api = &pivnetapi.PivnetApi{
+				Requester: requester,
+			}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+			server.Close()
+		})
+
+		It("downloads the files matching the globs into the destination", func() {
+			err := manifest.Sync(api, &manifest.Manifest{
+				Products: []manifest.Product{
+					{Name: "cf", Version: "1.8", Globs: []string{"*.pivotal", "*.pdf"}, Destination: filepath.Join(dir, "tiles")},
+				},
+			}, pivnetapi.DownloadOptions{})
+
+			Expect(err).ToNot(HaveOccurred())
+			_, productName := requester.GetProductContextArgsForCall(0)
+			Expect(productName).To(Equal("cf"))
+			Expect(requester.GetProductDownloadUrlContextCallCount()).To(Equal(2))
+			Expect(filepath.Join(dir, "tiles", "cf-1.8.3.pivotal")).To(BeAnExistingFile())
+			Expect(filepath.Join(dir, "tiles", "notes.pdf")).To(BeAnExistingFile())
+		})
+
+		It("downloads the first file of the file type without globs", func() {
+			err := manifest.Sync(api, &manifest.Manifest{
+				Products: []manifest.Product{
+					{Name: "cf", Destination: dir},
+				},
+			}, pivnetapi.DownloadOptions{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requester.GetProductDownloadUrlContextCallCount()).To(Equal(1))
+			_, productFile := requester.GetProductDownloadUrlContextArgsForCall(0)
+			Expect(productFile.Id).To(Equal(1))
+		})
+
+		It("skips files that were already downloaded", func() {
+			err := ioutil.WriteFile(filepath.Join(dir, "cf-1.8.3.pivotal"), []byte("aaa"), 0644)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = manifest.Sync(api, &manifest.Manifest{
+				Products: []manifest.Product{
+					{Name: "cf", Globs: []string{"*"}, Destination: dir},
+				},
+			}, pivnetapi.DownloadOptions{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requester.GetProductDownloadUrlContextCallCount()).To(Equal(2))
+		})
+
+		It("downloads files again that don't match the published size or checksum", func() {
+			requester.GetProductFilesContextReturns(&resource.ProductFiles{
+				Files: []resource.ProductFile{
+					resource.ProductFile{Id: 1, AwsObjectKey: "product/cf-1.8.3.pivotal", Size: 3},
+					resource.ProductFile{Id: 2, AwsObjectKey: "product/notes.pdf", Md5: "47bce5c74f589f4867dbd57e9ca9f808"},
+					resource.ProductFile{Id: 3, AwsObjectKey: "product/license.txt"},
+				},
+			}, nil)
+			err := ioutil.WriteFile(filepath.Join(dir, "cf-1.8.3.pivotal"), []byte("aa"), 0644)
+			Expect(err).ToNot(HaveOccurred())
+			err = ioutil.WriteFile(filepath.Join(dir, "notes.pdf"), []byte("bbb"), 0644)
+			Expect(err).ToNot(HaveOccurred())
+			err = ioutil.WriteFile(filepath.Join(dir, "license.txt"), []byte{}, 0644)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = manifest.Sync(api, &manifest.Manifest{
+				Products: []manifest.Product{
+					{Name: "cf", Globs: []string{"*"}, Destination: dir},
+				},
+			}, pivnetapi.DownloadOptions{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requester.GetProductDownloadUrlContextCallCount()).To(Equal(3))
+			for _, name := range []string{"cf-1.8.3.pivotal", "notes.pdf", "license.txt"} {
+				Expect(ioutil.ReadFile(filepath.Join(dir, name))).To(Equal([]byte("aaa")))
+			}
+		})
+
+		It("trusts the receipt of a file instead of hashing it", func() {
+			requester.GetProductFilesContextReturns(&resource.ProductFiles{
+				Files: []resource.ProductFile{
+					resource.ProductFile{Id: 1, AwsObjectKey: "product/cf-1.8.3.pivotal", Md5: "47bce5c74f589f4867dbd57e9ca9f808"},
+					resource.ProductFile{Id: 2, AwsObjectKey: "product/notes.pdf", Md5: "47bce5c74f589f4867dbd57e9ca9f808"},
+				},
+			}, nil)
+			for _, name := range []string{"cf-1.8.3.pivotal", "notes.pdf"} {
+				Expect(ioutil.WriteFile(filepath.Join(dir, name), []byte("bbb"), 0644)).To(Succeed())
+			}
+			// The receipt of the tile vouches for the published checksum, the
+			// receipt of the notes for an older file.
+			writeReceipt := func(name, md5 string) {
+				receipt, err := json.Marshal(pivnetapi.Receipt{Size: 3, Md5: md5, DownloadedAt: time.Now().Add(time.Minute)})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ioutil.WriteFile(pivnetapi.ReceiptName(filepath.Join(dir, name)), receipt, 0644)).To(Succeed())
+			}
+			writeReceipt("cf-1.8.3.pivotal", "47bce5c74f589f4867dbd57e9ca9f808")
+			writeReceipt("notes.pdf", "00000000000000000000000000000000")
+
+			err := manifest.Sync(api, &manifest.Manifest{
+				Products: []manifest.Product{
+					{Name: "cf", Globs: []string{"*"}, Destination: dir},
+				},
+			}, pivnetapi.DownloadOptions{})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requester.GetProductDownloadUrlContextCallCount()).To(Equal(1))
+			_, productFile := requester.GetProductDownloadUrlContextArgsForCall(0)
+			Expect(productFile.Id).To(Equal(2))
+			Expect(ioutil.ReadFile(filepath.Join(dir, "cf-1.8.3.pivotal"))).To(Equal([]byte("bbb")))
+		})
+
+		It("syncs the remaining products when one fails", func() {
+			requester.GetProductContextStub = func(ctx context.Context, productName string) (*resource.Product, error) {
+				if productName == "broken" {
+					return nil, errors.New("err")
+				}
+				return &resource.Product{
+					Releases: []resource.Release{resource.Release{Id: 1, Version: "1.8.3"}},
+				}, nil
+			}
+
+			err := manifest.Sync(api, &manifest.Manifest{
+				Products: []manifest.Product{
+					{Name: "broken", Destination: dir},
+					{Name: "cf", Destination: dir},
+				},
+			}, pivnetapi.DownloadOptions{})
+
+			var syncErr *manifest.SyncError
+			Expect(errors.As(err, &syncErr)).To(BeTrue())
+			Expect(syncErr.Products).To(Equal(2))
+			Expect(syncErr.Failures).To(HaveLen(1))
+			Expect(syncErr.Failures[0].Product.Name).To(Equal("broken"))
+			Expect(syncErr.Failures[0].Err).To(MatchError("err"))
+			Expect(filepath.Join(dir, "cf-1.8.3.pivotal")).To(BeAnExistingFile())
+		})
+
+		It("returns an error if no file matches", func() {
+			err := manifest.Sync(api, &manifest.Manifest{
+				Products: []manifest.Product{
+					{Name: "cf", Globs: []string{"*.tgz"}, Destination: dir},
+				},
+			}, pivnetapi.DownloadOptions{})
+
+			Expect(err).To(HaveOccurred())
+			Expect(requester.GetProductDownloadUrlContextCallCount()).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,166 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cfmobile/gopivnet/api"
+	"github.com/cfmobile/gopivnet/resource"
+)
+
+const defaultFileType = "pivotal"
+
+// ProductError is the error a product failed to sync with.
+type ProductError struct {
+	Product Product
+	Err     error
+}
+
+func (e *ProductError) Error() string {
+	name := e.Product.Name
+	if e.Product.Version != "" {
+		name += " " + e.Product.Version
+	}
+	return fmt.Sprintf("Unable to sync %s: %s", name, e.Err)
+}
+
+func (e *ProductError) Unwrap() error {
+	return e.Err
+}
+
+// SyncError is returned by Sync when some products failed to sync.
+type SyncError struct {
+	Failures []*ProductError
+	Products int
+}
+
+func (e *SyncError) Error() string {
+	return fmt.Sprintf("%d of %d products failed to sync", len(e.Failures), e.Products)
+}
+
+// Sync downloads every file listed in the manifest that is not already in
+// its destination with the size and checksum published for it. Products that
+// fail are skipped so that one bad entry doesn't stop the rest of the mirror;
+// the returned *SyncError lists those that failed.
+func Sync(pivnetApi api.Api, m *Manifest, options api.DownloadOptions) error {
+	return SyncContext(context.Background(), pivnetApi, m, options)
+}
+
+// SyncContext is Sync, stopping at the first error once ctx is done.
+func SyncContext(ctx context.Context, pivnetApi api.Api, m *Manifest, options api.DownloadOptions) error {
+	syncErr := &SyncError{Products: len(m.Products)}
+	for _, product := range m.Products {
+		err := syncProduct(ctx, pivnetApi, product, options)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			syncErr.Failures = append(syncErr.Failures, &ProductError{Product: product, Err: err})
+		}
+	}
+
+	if len(syncErr.Failures) > 0 {
+		return syncErr
+	}
+	return nil
+}
+
+func syncProduct(ctx context.Context, pivnetApi api.Api, product Product, options api.DownloadOptions) error {
+	version := product.Version
+	if options.Done != nil {
+		// Resolve the release once so results can say which one it is.
+		release, err := pivnetApi.ResolveVersionContext(ctx, product.Name, version)
+		if err != nil {
+			return err
+		}
+		version = release.Version
+		options = options.ForRelease(product.Name, *release)
+	}
+
+	productFiles, err := pivnetApi.GetProductFilesForVersionContext(ctx, product.Name, version)
+	if err != nil {
+		return err
+	}
+
+	files, err := selectFiles(productFiles, product)
+	if err != nil {
+		return err
+	}
+
+	destination := product.Destination
+	if destination == "" {
+		destination = "."
+	}
+	err = os.MkdirAll(destination, 0755)
+	if err != nil {
+		return err
+	}
+
+	for index := range files {
+		fileName := filepath.Join(destination, files[index].Name())
+		if synced(&files[index], fileName) {
+			continue
+		}
+
+		err = pivnetApi.DownloadContext(ctx, &files[index], fileName, options)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// synced reports whether fileName already holds productFile. A receipt
+// written with the file vouches for its checksum as long as the file hasn't
+// changed since, so multi-GB tiles are only hashed when there is none. Empty
+// files are always downloaded again, since a file without a published size or
+// checksum can't be told apart from one a failed run left behind.
+func synced(productFile *resource.ProductFile, fileName string) bool {
+	info, err := os.Stat(fileName)
+	if err != nil || info.Size() == 0 {
+		return false
+	}
+	if productFile.Size > 0 && info.Size() != productFile.Size {
+		return false
+	}
+
+	receipt, err := api.ReadReceipt(fileName)
+	if err == nil && receipt.Size == info.Size() && !info.ModTime().After(receipt.DownloadedAt) {
+		return receiptMatches(receipt, productFile)
+	}
+	return api.CheckFile(productFile, fileName) == nil
+}
+
+// receiptMatches reports whether receipt records the checksum published for
+// productFile, or the same file if none is published.
+func receiptMatches(receipt *api.Receipt, productFile *resource.ProductFile) bool {
+	switch {
+	case productFile.Sha256 != "":
+		return strings.EqualFold(receipt.Sha256, productFile.Sha256)
+	case productFile.Md5 != "":
+		return strings.EqualFold(receipt.Md5, productFile.Md5)
+	}
+	return receipt.ProductFileId == productFile.Id
+}
+
+// selectFiles returns the files matching any of the product's globs. Without
+// globs it falls back to the first file of the product's file type, like the
+// single product cli does.
+func selectFiles(productFiles *resource.ProductFiles, product Product) ([]resource.ProductFile, error) {
+	if len(product.Globs) == 0 {
+		fileType := product.FileType
+		if fileType == "" {
+			fileType = defaultFileType
+		}
+		files, err := api.SelectFiles(productFiles, api.FileSelector{FileType: fileType})
+		if err != nil {
+			return nil, err
+		}
+		return files[:1], nil
+	}
+
+	return api.SelectGlobs(productFiles, product.FileType, product.Globs)
+}
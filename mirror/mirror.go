@@ -0,0 +1,502 @@
+package mirror
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cfmobile/gopivnet/api"
+	"github.com/cfmobile/gopivnet/resource"
+	"github.com/cfmobile/gopivnet/semver"
+)
+
+// Mirror serves the files of a directory laid out as
+//
+//	<dir>/<product slug>/<version>/<file>
+//
+// through the part of the Pivotal Network api used to find and download
+// product files, so clients only need to change the api url. Ids are derived
+// from the names, so they stay the same as long as the files don't move.
+// Files are served to any token, refresh tokens are exchanged for a dummy
+// access token and no eula has to be accepted.
+//
+// Checksums come from the receipts of the files. Files without one are hashed
+// in the background from the start, and a listing waits for the files it
+// lists, so no file is ever listed without its checksums.
+type Mirror struct {
+	dir       string
+	routes    []route
+	mu        sync.Mutex
+	checksums map[string]checksums
+	hashing   map[string]*hashing
+	// hashers limits the files hashed at once.
+	hashers chan struct{}
+}
+
+// hashing is a file being hashed; done is closed once sums or err are set.
+type hashing struct {
+	done chan struct{}
+	sums checksums
+	err  error
+}
+
+// route matches a method and the segments of a path, where segments starting
+// with ":" match any value and are passed to the handler by name.
+type route struct {
+	method   string
+	segments []string
+	handler  func(w http.ResponseWriter, r *http.Request, params map[string]string)
+}
+
+type checksums struct {
+	size    int64
+	modTime time.Time
+	sha256  string
+	md5     string
+}
+
+type release struct {
+	slug    string
+	version string
+	dir     string
+}
+
+func New(dir string) *Mirror {
+	m := &Mirror{
+		dir:       dir,
+		checksums: map[string]checksums{},
+		hashing:   map[string]*hashing{},
+		hashers:   make(chan struct{}, 2),
+	}
+	m.handle("POST", "/api/v2/authentication/access_tokens", m.accessToken)
+	m.handle("GET", "/api/v2/products", m.products)
+	m.handle("GET", "/api/v2/products/:slug/releases", m.releases)
+	m.handle("GET", "/api/v2/products/:slug/releases/:id", m.release)
+	m.handle("GET", "/api/v2/products/:slug/releases/:id/product_files", m.productFiles)
+	m.handle("GET", "/api/v2/products/:slug/releases/:id/product_files/:file", m.productFileById)
+	m.handle("POST", "/api/v2/products/:slug/releases/:id/product_files/:file/download", m.download)
+	m.handle("POST", "/api/v2/products/:slug/releases/:id/eula_acceptance", m.acceptEula)
+	m.handle("GET", "/files/:slug/:version/:name", m.serveFile)
+	go m.hashAll()
+	return m
+}
+
+func (m *Mirror) handle(method, pattern string, handler func(w http.ResponseWriter, r *http.Request, params map[string]string)) {
+	m.routes = append(m.routes, route{method: method, segments: strings.Split(strings.Trim(pattern, "/"), "/"), handler: handler})
+}
+
+func (m *Mirror) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	allowed := false
+	for _, rt := range m.routes {
+		params, ok := rt.match(segments)
+		if !ok {
+			continue
+		}
+		if rt.method != r.Method {
+			allowed = true
+			continue
+		}
+		rt.handler(w, r, params)
+		return
+	}
+
+	if allowed {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	notFound(w)
+}
+
+func (rt route) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(rt.segments) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for index, segment := range rt.segments {
+		switch {
+		case strings.HasPrefix(segment, ":") && segments[index] != "":
+			params[segment[1:]] = segments[index]
+		case segment != segments[index]:
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// accessToken answers the exchange of UAA refresh tokens, so clients
+// configured with one work unchanged. Any token is accepted anyway.
+func (m *Mirror) accessToken(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	writeJSON(w, map[string]string{"access_token": "mirror"})
+}
+
+func (m *Mirror) products(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	products := resource.Products{Products: []resource.ProductSummary{}}
+	for _, slug := range subdirectories(m.dir) {
+		products.Products = append(products.Products, resource.ProductSummary{
+			Id:   id(slug),
+			Slug: slug,
+			Name: slug,
+			Links: resource.Links{
+				"releases": resource.Link{Url: fmt.Sprintf("%s/api/v2/products/%s/releases", baseUrl(r), slug)},
+			},
+		})
+	}
+	writeJSON(w, products)
+}
+
+func (m *Mirror) releases(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	releases, ok := m.productReleases(params["slug"])
+	if !ok {
+		notFound(w)
+		return
+	}
+
+	product := resource.Product{Releases: []resource.Release{}}
+	for _, rel := range releases {
+		product.Releases = append(product.Releases, m.releaseJSON(r, rel))
+	}
+	writeJSON(w, product)
+}
+
+func (m *Mirror) release(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	rel, ok := m.findRelease(params)
+	if !ok {
+		notFound(w)
+		return
+	}
+	writeJSON(w, m.releaseJSON(r, rel))
+}
+
+func (m *Mirror) productFiles(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	rel, ok := m.findRelease(params)
+	if !ok {
+		notFound(w)
+		return
+	}
+
+	productFiles := resource.ProductFiles{Files: []resource.ProductFile{}}
+	for _, name := range files(rel.dir) {
+		productFile, err := m.productFile(r, rel, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		productFiles.Files = append(productFiles.Files, productFile)
+	}
+	writeJSON(w, productFiles)
+}
+
+func (m *Mirror) productFileById(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	rel, ok := m.findRelease(params)
+	if !ok {
+		notFound(w)
+		return
+	}
+
+	for _, name := range files(rel.dir) {
+		if strconv.Itoa(id(rel.slug, rel.version, name)) != params["file"] {
+			continue
+		}
+		productFile, err := m.productFile(r, rel, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]resource.ProductFile{"product_file": productFile})
+		return
+	}
+	notFound(w)
+}
+
+func (m *Mirror) download(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	rel, ok := m.findRelease(params)
+	if !ok {
+		notFound(w)
+		return
+	}
+
+	for _, name := range files(rel.dir) {
+		if strconv.Itoa(id(rel.slug, rel.version, name)) == params["file"] {
+			w.Header().Set("Location", fileUrl(r, rel, name))
+			w.WriteHeader(http.StatusFound)
+			return
+		}
+	}
+	notFound(w)
+}
+
+func (m *Mirror) acceptEula(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if _, ok := m.findRelease(params); !ok {
+		notFound(w)
+		return
+	}
+	writeJSON(w, map[string]string{})
+}
+
+func (m *Mirror) serveFile(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	rel, ok := m.findVersion(params["slug"], params["version"])
+	name := params["name"]
+	if !ok || !contains(files(rel.dir), name) {
+		notFound(w)
+		return
+	}
+	http.ServeFile(w, r, filepath.Join(rel.dir, name))
+}
+
+func (m *Mirror) releaseJSON(r *http.Request, rel release) resource.Release {
+	releaseUrl := fmt.Sprintf("%s/api/v2/products/%s/releases/%d", baseUrl(r), rel.slug, id(rel.slug, rel.version))
+	releaseDate := ""
+	if info, err := os.Stat(rel.dir); err == nil {
+		releaseDate = info.ModTime().Format(api.ReleaseDateFormat)
+	}
+
+	return resource.Release{
+		Id:           id(rel.slug, rel.version),
+		Version:      rel.version,
+		ReleaseDate:  releaseDate,
+		Availability: "All Users",
+		Links: resource.Links{
+			"self":          resource.Link{Url: releaseUrl},
+			"product_files": resource.Link{Url: releaseUrl + "/product_files"},
+		},
+	}
+}
+
+func (m *Mirror) productFile(r *http.Request, rel release, name string) (resource.ProductFile, error) {
+	fileId := id(rel.slug, rel.version, name)
+	fileName := filepath.Join(rel.dir, name)
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return resource.ProductFile{}, err
+	}
+	sums, err := m.checksum(r.Context(), fileName, info)
+	if err != nil {
+		return resource.ProductFile{}, err
+	}
+
+	return resource.ProductFile{
+		Id:           fileId,
+		DisplayName:  name,
+		AwsObjectKey: path.Join("product-files", rel.slug, name),
+		FileVersion:  rel.version,
+		Size:         info.Size(),
+		Sha256:       sums.sha256,
+		Md5:          sums.md5,
+		Links: resource.Links{
+			"download": resource.Link{Url: fmt.Sprintf("%s/api/v2/products/%s/releases/%d/product_files/%d/download", baseUrl(r), rel.slug, id(rel.slug, rel.version), fileId)},
+		},
+	}, nil
+}
+
+// checksum returns the checksums of fileName from its receipt, or from an
+// earlier hash if the file didn't change since. Otherwise it waits for the
+// file to be hashed.
+func (m *Mirror) checksum(ctx context.Context, fileName string, info os.FileInfo) (checksums, error) {
+	receipt, err := api.ReadReceipt(fileName)
+	if err == nil && receipt.Size == info.Size() && receipt.Sha256 != "" && !info.ModTime().After(receipt.DownloadedAt) {
+		return checksums{size: info.Size(), modTime: info.ModTime(), sha256: receipt.Sha256, md5: receipt.Md5}, nil
+	}
+
+	m.mu.Lock()
+	sums, ok := m.checksums[fileName]
+	if ok && sums.size == info.Size() && sums.modTime.Equal(info.ModTime()) {
+		m.mu.Unlock()
+		return sums, nil
+	}
+	h, ok := m.hashing[fileName]
+	if !ok {
+		h = &hashing{done: make(chan struct{})}
+		m.hashing[fileName] = h
+		go m.hash(fileName, info, h)
+	}
+	m.mu.Unlock()
+
+	select {
+	case <-h.done:
+		return h.sums, h.err
+	case <-ctx.Done():
+		return checksums{}, ctx.Err()
+	}
+}
+
+func (m *Mirror) hash(fileName string, info os.FileInfo, h *hashing) {
+	m.hashers <- struct{}{}
+	h.sums, h.err = hashFile(fileName, info)
+	<-m.hashers
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.hashing, fileName)
+	if h.err == nil {
+		m.checksums[fileName] = h.sums
+	}
+	close(h.done)
+}
+
+// hashAll hashes the files without a receipt ahead of their first listing.
+func (m *Mirror) hashAll() {
+	for _, slug := range subdirectories(m.dir) {
+		releases, _ := m.productReleases(slug)
+		for _, rel := range releases {
+			for _, name := range files(rel.dir) {
+				fileName := filepath.Join(rel.dir, name)
+				if info, err := os.Stat(fileName); err == nil {
+					m.checksum(context.Background(), fileName, info)
+				}
+			}
+		}
+	}
+}
+
+func hashFile(fileName string, info os.FileInfo) (checksums, error) {
+	in, err := os.Open(fileName)
+	if err != nil {
+		return checksums{}, err
+	}
+	defer in.Close()
+
+	sha := sha256.New()
+	md := md5.New()
+	_, err = io.Copy(io.MultiWriter(sha, md), in)
+	if err != nil {
+		return checksums{}, err
+	}
+
+	return checksums{
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		sha256:  hex.EncodeToString(sha.Sum(nil)),
+		md5:     hex.EncodeToString(md.Sum(nil)),
+	}, nil
+}
+
+// productReleases returns the releases of the product, latest first as
+// Pivotal Network lists them.
+func (m *Mirror) productReleases(slug string) ([]release, bool) {
+	productDir := filepath.Join(m.dir, slug)
+	if !contains(subdirectories(m.dir), slug) {
+		return nil, false
+	}
+
+	releases := []release{}
+	for _, version := range subdirectories(productDir) {
+		releases = append(releases, release{slug: slug, version: version, dir: filepath.Join(productDir, version)})
+	}
+	sort.SliceStable(releases, func(i, j int) bool {
+		return newer(releases[i].version, releases[j].version)
+	})
+	return releases, true
+}
+
+func (m *Mirror) findRelease(params map[string]string) (release, bool) {
+	releases, _ := m.productReleases(params["slug"])
+	for _, rel := range releases {
+		if strconv.Itoa(id(rel.slug, rel.version)) == params["id"] {
+			return rel, true
+		}
+	}
+	return release{}, false
+}
+
+func (m *Mirror) findVersion(slug, version string) (release, bool) {
+	releases, _ := m.productReleases(slug)
+	for _, rel := range releases {
+		if rel.version == version {
+			return rel, true
+		}
+	}
+	return release{}, false
+}
+
+func newer(a, b string) bool {
+	va, errA := semver.Parse(a)
+	vb, errB := semver.Parse(b)
+	if errA != nil || errB != nil {
+		return a > b
+	}
+	return vb.LessThan(va)
+}
+
+// id derives a stable positive id from names.
+func id(names ...string) int {
+	h := fnv.New32a()
+	h.Write([]byte(strings.Join(names, "/")))
+	return int(h.Sum32() & 0x7fffffff)
+}
+
+func subdirectories(dir string) []string {
+	return entries(dir, true)
+}
+
+// files lists the files of dir, leaving out partial downloads and receipts.
+func files(dir string) []string {
+	names := []string{}
+	for _, name := range entries(dir, false) {
+		if !strings.HasSuffix(name, api.PartialSuffix) && !strings.HasSuffix(name, api.ReceiptSuffix) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func entries(dir string, directories bool) []string {
+	infos, _ := ioutil.ReadDir(dir)
+	names := []string{}
+	for _, info := range infos {
+		if strings.HasPrefix(info.Name(), ".") || info.IsDir() != directories {
+			continue
+		}
+		names = append(names, info.Name())
+	}
+	return names
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func fileUrl(r *http.Request, rel release, name string) string {
+	return fmt.Sprintf("%s/files/%s/%s/%s", baseUrl(r), url.PathEscape(rel.slug), url.PathEscape(rel.version), url.PathEscape(name))
+}
+
+func baseUrl(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func notFound(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": http.StatusNotFound, "message": "not found"})
+}
@@ -0,0 +1,168 @@
+package mirror_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/cfmobile/gopivnet/api"
+	"github.com/cfmobile/gopivnet/mirror"
+	"github.com/cfmobile/gopivnet/resource"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Mirror", func() {
+	var dir string
+	var server *httptest.Server
+	var pivnetApi api.Api
+
+	writeFile := func(name, content string) {
+		fileName := filepath.Join(dir, name)
+		Expect(os.MkdirAll(filepath.Dir(fileName), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(fileName, []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		writeFile("p-redis/1.8.2/p-redis-1.8.2.pivotal", "redis 1.8.2")
+		writeFile("p-redis/1.10.0/p-redis-1.10.0.pivotal", "redis 1.10.0")
+		writeFile("p-redis/1.10.0/release-notes.pdf", "notes")
+		writeFile("p-redis/1.10.0/p-redis-1.10.0.pivotal"+api.PartialSuffix, "red")
+		writeFile("p-redis/1.10.0/p-redis-1.10.0.pivotal"+api.ReceiptSuffix, "{}")
+		writeFile("p-redis/1.10.0/.DS_Store", "")
+		writeFile("stemcells/3263.10/bosh-stemcell-vsphere.tgz", "stemcell")
+
+		server = httptest.NewServer(mirror.New(dir))
+		pivnetApi = api.New("token", api.WithEndpoint(server.URL))
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("lists the products", func() {
+		products, err := pivnetApi.GetProducts()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(products).To(HaveLen(2))
+		Expect(products[0].Slug).To(Equal("p-redis"))
+		Expect(products[1].Slug).To(Equal("stemcells"))
+	})
+
+	It("lists the releases latest first", func() {
+		versions, err := pivnetApi.GetVersionsForProduct("p-redis")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(versions).To(Equal([]string{"1.10.0", "1.8.2"}))
+
+		release, err := pivnetApi.ResolveVersion("p-redis", "~> 1.8.0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(release.Version).To(Equal("1.8.2"))
+	})
+
+	It("lists the files of a release with their checksums", func() {
+		productFiles, err := pivnetApi.GetProductFilesForVersion("p-redis", "1.10.0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(productFiles.Files).To(HaveLen(2))
+
+		productFile := productFiles.Files[0]
+		Expect(productFile.Name()).To(Equal("p-redis-1.10.0.pivotal"))
+		Expect(productFile.FileVersion).To(Equal("1.10.0"))
+		Expect(productFile.Size).To(Equal(int64(12)))
+		Expect(productFile.Sha256).To(Equal("8fa100661d18665b46daadc194b01297108e2479f3138e2258117680d9520da7"))
+		Expect(productFile.Md5).To(HaveLen(32))
+	})
+
+	It("takes the checksums from the receipts of the files", func() {
+		writeFile("p-redis/1.8.2/p-redis-1.8.2.pivotal"+api.ReceiptSuffix, `{"size":11,"sha256":"abc","md5":"def","downloaded_at":"2100-01-01T00:00:00Z"}`)
+
+		productFiles, err := pivnetApi.GetProductFilesForVersion("p-redis", "1.8.2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(productFiles.Files[0].Sha256).To(Equal("abc"))
+		Expect(productFiles.Files[0].Md5).To(Equal("def"))
+	})
+
+	It("accepts UAA refresh tokens", func() {
+		versions, err := api.New("token-r", api.WithEndpoint(server.URL)).GetVersionsForProduct("p-redis")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(versions).To(Equal([]string{"1.10.0", "1.8.2"}))
+	})
+
+	It("rejects other methods", func() {
+		resp, err := http.Post(server.URL+"/api/v2/products", "application/json", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+	})
+
+	It("keeps ids stable across mirrors of the same files", func() {
+		first, err := pivnetApi.GetProductFilesForVersion("p-redis", "1.10.0")
+		Expect(err).ToNot(HaveOccurred())
+
+		other := httptest.NewServer(mirror.New(dir))
+		defer other.Close()
+		second, err := api.New("token", api.WithEndpoint(other.URL)).GetProductFilesForVersion("p-redis", "1.10.0")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(second.Files[0].Id).To(Equal(first.Files[0].Id))
+	})
+
+	It("downloads files and verifies their checksums", func() {
+		productFile, err := pivnetApi.GetProductFile("p-redis", "latest", api.FileSelector{Glob: "*.pivotal"})
+		Expect(err).ToNot(HaveOccurred())
+
+		fileName := filepath.Join(dir, "download.pivotal")
+		err = pivnetApi.DownloadWithOptions(productFile, fileName, api.DownloadOptions{Parallel: 3})
+		Expect(err).ToNot(HaveOccurred())
+
+		content, err := ioutil.ReadFile(fileName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("redis 1.10.0"))
+	})
+
+	It("verifies downloaded files against their receipts", func() {
+		productFile, err := pivnetApi.GetProductFile("p-redis", "1.8.2", api.FileSelector{})
+		Expect(err).ToNot(HaveOccurred())
+
+		fileName := filepath.Join(dir, "download.pivotal")
+		err = pivnetApi.DownloadWithOptions(productFile, fileName, api.DownloadOptions{Receipt: true})
+		Expect(err).ToNot(HaveOccurred())
+
+		receipt, err := pivnetApi.VerifyFile(fileName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(receipt.Product).To(Equal("p-redis"))
+		Expect(receipt.ProductFileId).To(Equal(productFile.Id))
+	})
+
+	It("returns not found for unknown products and files", func() {
+		_, err := pivnetApi.GetVersionsForProduct("p-mysql")
+		Expect(err).To(HaveOccurred())
+
+		resp, err := http.Get(server.URL + "/files/p-redis/1.10.0/p-redis-1.10.0.pivotal" + api.PartialSuffix)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+
+		resp, err = http.Get(server.URL + "/files/p-redis/1.10.0/..%2f..%2fstemcells%2f3263.10%2fbosh-stemcell-vsphere.tgz")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+	})
+
+	It("serves the same shapes as Pivotal Network to the requester", func() {
+		requester := resource.NewRequester(server.URL, "token")
+		product, err := requester.GetProduct("stemcells")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(product.Releases).To(HaveLen(1))
+
+		productFiles, err := requester.GetProductFiles(product.Releases[0])
+		Expect(err).ToNot(HaveOccurred())
+
+		url, err := requester.GetProductDownloadUrl(&productFiles.Files[0])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(url).To(Equal(server.URL + "/files/stemcells/3263.10/bosh-stemcell-vsphere.tgz"))
+	})
+})
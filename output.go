@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v2"
+)
+
+var outputFormats = []string{"table", "json", "yaml"}
+
+// listing is what the list commands print. The table format shows header and
+// rows, while json and yaml marshal data, so they can carry full values such
+// as sizes in bytes.
+type listing struct {
+	header []string
+	rows   [][]string
+	data   interface{}
+}
+
+func (l listing) print(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		out, err := json.MarshalIndent(l.data, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", out)
+		return err
+	case "yaml":
+		out, err := yaml.Marshal(l.data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(l.header, "\t"))
+	for _, row := range l.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func validOutputFormat(format string) bool {
+	for _, valid := range outputFormats {
+		if format == valid {
+			return true
+		}
+	}
+	return false
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value := float64(size)
+	suffix := 0
+	for value >= unit && suffix < 5 {
+		value /= unit
+		suffix++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "KMGTP"[suffix-1])
+}
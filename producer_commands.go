@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cfmobile/gopivnet/resource"
+)
+
+func createReleaseCommand(args []string) {
+	cmd := newCommand("create-release")
+	product := cmd.flags.String("product", "", "product to create the release for")
+	version := cmd.flags.String("version", "", "version of the release")
+	releaseType := cmd.flags.String("release-type", "", "release type, e.g. 'Minor Release'")
+	eulaSlug := cmd.flags.String("eula-slug", "", "slug of the eula users must accept")
+	description := cmd.flags.String("description", "", "description of the release")
+	releaseNotesUrl := cmd.flags.String("release-notes-url", "", "url of the release notes")
+	releaseDate := cmd.flags.String("release-date", "", "release date, formatted as YYYY-MM-DD. Defaults to today")
+	availability := cmd.flags.String("availability", "Admins Only", "who can see the release: 'Admins Only', 'All Users' or 'Selected User Groups Only'")
+	userGroups := cmd.flags.String("user-groups", "", "comma separated ids of the user groups that can see the release. Implies -availability 'Selected User Groups Only'")
+	pivnetApi, ctx, cancel := cmd.parse(args)
+	defer cancel()
+
+	if *product == "" {
+		usageError("Need a product name")
+	}
+	if *version == "" || *releaseType == "" || *eulaSlug == "" {
+		usageError("Need -version, -release-type and -eula-slug")
+	}
+
+	userGroupIds := []int{}
+	if *userGroups != "" {
+		for _, id := range strings.Split(*userGroups, ",") {
+			userGroupId, err := strconv.Atoi(strings.TrimSpace(id))
+			if err != nil {
+				usageError(fmt.Sprintf("Invalid user group id %q", id))
+			}
+			userGroupIds = append(userGroupIds, userGroupId)
+		}
+	}
+
+	release, err := pivnetApi.CreateReleaseContext(ctx, *product, resource.NewRelease{
+		Version:         *version,
+		ReleaseType:     *releaseType,
+		EulaSlug:        *eulaSlug,
+		Description:     *description,
+		ReleaseNotesUrl: *releaseNotesUrl,
+		ReleaseDate:     *releaseDate,
+		Availability:    *availability,
+	}, userGroupIds)
+	if err != nil {
+		fatal(err)
+	}
+
+	cmd.print(listing{
+		header: []string{"ID", "VERSION", "TYPE", "AVAILABILITY"},
+		rows:   [][]string{{strconv.Itoa(release.Id), release.Version, release.ReleaseType, release.Availability}},
+		data: releaseRow{
+			Id:           release.Id,
+			Version:      release.Version,
+			ReleaseType:  release.ReleaseType,
+			ReleaseDate:  release.ReleaseDate,
+			Availability: release.Availability,
+			Eula:         release.Eula.Slug,
+		},
+	})
+}
+
+func uploadFileCommand(args []string) {
+	cmd := newCommand("upload-file")
+	product := cmd.flags.String("product", "", "product to upload the file to")
+	version := cmd.flags.String("version", "", "version of the release the file is added to")
+	file := cmd.flags.String("file", "", "path of the file to upload")
+	name := cmd.flags.String("name", "", "name shown on Pivotal Network. Defaults to the file name")
+	fileVersion := cmd.flags.String("file-version", "", "version of the file. Defaults to the release version")
+	fileType := cmd.flags.String("file-type", "", "type of the file, e.g. 'Software' or 'Documentation'. Defaults to 'Software'")
+	description := cmd.flags.String("description", "", "description of the file")
+	objectKey := cmd.flags.String("object-key", "", "key of the file in the Pivotal Network bucket. Defaults to product-files/<product>/<file name>")
+	pivnetApi, ctx, cancel := cmd.parse(args)
+	defer cancel()
+
+	if *product == "" {
+		usageError("Need a product name")
+	}
+	if *version == "" || *file == "" {
+		usageError("Need -version and -file")
+	}
+
+	productFile, err := pivnetApi.UploadProductFileContext(ctx, *product, *version, *file, resource.NewProductFile{
+		Name:         *name,
+		AwsObjectKey: *objectKey,
+		FileVersion:  *fileVersion,
+		FileType:     *fileType,
+		Description:  *description,
+	})
+	if err != nil {
+		fatal(err)
+	}
+
+	cmd.print(listing{
+		header: []string{"ID", "NAME", "FILE", "VERSION", "CHECKSUM"},
+		rows:   [][]string{{strconv.Itoa(productFile.Id), productFile.DisplayName, productFile.Name(), productFile.FileVersion, checksum(*productFile)}},
+		data: fileRow{
+			Id:          productFile.Id,
+			Name:        productFile.DisplayName,
+			File:        productFile.Name(),
+			FileVersion: productFile.FileVersion,
+			Size:        productFile.Size,
+			Sha256:      productFile.Sha256,
+			Md5:         productFile.Md5,
+		},
+	})
+}
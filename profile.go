@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/cfmobile/gopivnet/api"
+	"github.com/cfmobile/gopivnet/config"
+)
+
+const profileEnv = "GOPIVNET_PROFILE"
+
+type profileFlags struct {
+	name       *string
+	configFile *string
+	endpoint   *string
+}
+
+func addProfileFlags(flags *flag.FlagSet) *profileFlags {
+	return &profileFlags{
+		name:       flags.String("profile", os.Getenv(profileEnv), "profile of the config file to use. Defaults to $"+profileEnv+" or the default profile"),
+		configFile: flags.String("config", "", "config file holding the profiles. Defaults to ~/"+config.FileName),
+		endpoint:   flags.String("endpoint", "", "url of the Pivotal Network api, e.g. of an internal mirror. Defaults to the profile's url, $GOPIVNET_URL or "+api.PivnetUrl),
+	}
+}
+
+// load returns the selected profile with the environment and the -endpoint
+// flag applied. A missing default config file is the same as an empty one.
+func (f *profileFlags) load() config.Profile {
+	configFile := *f.configFile
+	if configFile == "" {
+		configFile = config.DefaultPath()
+	}
+
+	c := &config.Config{}
+	if configFile != "" {
+		loaded, err := config.Load(configFile)
+		switch {
+		case err == nil:
+			c = loaded
+		case !os.IsNotExist(err) || *f.configFile != "":
+			fatal(err)
+		}
+	}
+
+	profile, err := c.Profile(*f.name)
+	if err != nil {
+		usageError(err.Error())
+	}
+
+	profile = profile.WithEnv(os.LookupEnv)
+	if *f.endpoint != "" {
+		profile.Url = *f.endpoint
+	}
+	return profile
+}
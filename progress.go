@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cfmobile/gopivnet/api"
+)
+
+const (
+	barWidth            = 30
+	terminalInterval    = 200 * time.Millisecond
+	logProgressInterval = 30 * time.Second
+)
+
+// progressBar draws the progress of a download on a terminal. When stdout
+// isn't a terminal, such as in CI, it logs a line at every update instead.
+type progressBar struct {
+	out io.Writer
+	tty bool
+}
+
+// printDownloads prints a message for every file written, whether or not
+// progress is shown.
+func printDownloads(options *api.DownloadOptions) {
+	options.Done = func(result api.DownloadResult) {
+		if result.Cached {
+			fmt.Printf("Using cached \"%s\"\n", result.FileName)
+			return
+		}
+		fmt.Printf("Wrote %d bytes to \"%s\"\n", result.Bytes, result.FileName)
+	}
+}
+
+// progressOptions sets up download progress reporting. A zero interval picks
+// one suited to the output.
+func progressOptions(options *api.DownloadOptions, interval time.Duration) {
+	bar := &progressBar{out: os.Stdout, tty: isTerminal(os.Stdout)}
+	if interval == 0 {
+		interval = logProgressInterval
+		if bar.tty {
+			interval = terminalInterval
+		}
+	}
+
+	options.Progress = bar
+	options.ProgressInterval = interval
+}
+
+func (b *progressBar) Update(progress api.Progress) {
+	name := filepath.Base(progress.FileName)
+
+	if !b.tty {
+		if !progress.Complete {
+			log.Printf("%s: %s", name, describeProgress(progress))
+		}
+		return
+	}
+
+	bar := strings.Repeat(" ", barWidth)
+	if progress.Total > 0 {
+		// Done can pass Total when the server sends more than it announced.
+		filled := int(progress.Done * barWidth / progress.Total)
+		if filled < 0 {
+			filled = 0
+		} else if filled > barWidth {
+			filled = barWidth
+		}
+		bar = strings.Repeat("=", filled) + strings.Repeat(" ", barWidth-filled)
+	}
+	fmt.Fprintf(b.out, "\r\033[K%s [%s] %s", name, bar, describeProgress(progress))
+	if progress.Complete {
+		fmt.Fprintln(b.out)
+	}
+}
+
+func describeProgress(progress api.Progress) string {
+	rate := formatSize(int64(progress.Rate)) + "/s"
+	if progress.Total <= 0 {
+		return fmt.Sprintf("%s at %s", formatSize(progress.Done), rate)
+	}
+
+	description := fmt.Sprintf("%d%% %s of %s at %s", progress.Done*100/progress.Total,
+		formatSize(progress.Done), formatSize(progress.Total), rate)
+	if eta := progress.ETA(); eta > 0 {
+		description += ", ETA " + eta.Round(time.Second).String()
+	}
+	return description
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
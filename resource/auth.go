@@ -0,0 +1,149 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// refreshTokenSuffix ends the UAA refresh tokens Pivotal Network issues. The
+// legacy api tokens don't have it.
+const refreshTokenSuffix = "-r"
+
+// Authenticator supplies the Authorization header of every request to
+// Pivotal Network.
+type Authenticator interface {
+	Authorization(ctx context.Context) (string, error)
+	// Expire is called when the server rejects authorization. It returns
+	// true if a new call to Authorization may succeed where it failed.
+	Expire(authorization string) bool
+}
+
+// WithAuthenticator authenticates requests with auth instead of the token
+// passed to NewRequester.
+func WithAuthenticator(auth Authenticator) ClientOption {
+	return func(c *requesterConfig) {
+		c.authenticator = auth
+	}
+}
+
+type legacyToken string
+
+// LegacyToken authenticates with a legacy api token, which never expires.
+func LegacyToken(token string) Authenticator {
+	return legacyToken(token)
+}
+
+func (t legacyToken) Authorization(context.Context) (string, error) {
+	return "Token " + string(t), nil
+}
+
+func (t legacyToken) Expire(string) bool {
+	return false
+}
+
+// RefreshToken exchanges a UAA refresh token for a short lived access token
+// at pivnetUrl, and for a new one whenever the server rejects it. Requesters
+// sharing it exchange the token with their own client and request timeout.
+func RefreshToken(pivnetUrl, refreshToken string) Authenticator {
+	return &refreshTokenAuth{
+		pivnetUrl:    pivnetUrl,
+		refreshToken: refreshToken,
+	}
+}
+
+// NewAuthenticator picks the Authenticator for token by its format: UAA
+// refresh tokens end in "-r", anything else is sent as a legacy api token.
+// Pass RefreshToken or LegacyToken to WithAuthenticator to choose explicitly.
+func NewAuthenticator(pivnetUrl, token string) Authenticator {
+	if strings.HasSuffix(token, refreshTokenSuffix) {
+		return RefreshToken(pivnetUrl, token)
+	}
+	return LegacyToken(token)
+}
+
+type exchangeClientKey struct{}
+
+// withExchangeClient has refresh tokens exchanged with client for requests
+// made with ctx.
+func withExchangeClient(ctx context.Context, client *http.Client) context.Context {
+	return context.WithValue(ctx, exchangeClientKey{}, client)
+}
+
+type refreshTokenAuth struct {
+	pivnetUrl    string
+	refreshToken string
+
+	mu          sync.Mutex
+	accessToken string
+}
+
+func (r *refreshTokenAuth) Authorization(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.accessToken == "" {
+		accessToken, err := r.exchange(ctx)
+		if err != nil {
+			return "", err
+		}
+		r.accessToken = accessToken
+	}
+	return "Bearer " + r.accessToken, nil
+}
+
+// Expire forgets the access token, unless another request has already
+// replaced it.
+func (r *refreshTokenAuth) Expire(authorization string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if authorization == "Bearer "+r.accessToken {
+		r.accessToken = ""
+	}
+	return true
+}
+
+func (r *refreshTokenAuth) exchange(ctx context.Context) (string, error) {
+	requestUrl := fmt.Sprintf("%s/api/v2/authentication/access_tokens", r.pivnetUrl)
+	payload, _ := json.Marshal(map[string]string{"refresh_token": r.refreshToken})
+	req, err := http.NewRequestWithContext(ctx, "POST", requestUrl, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf(`gopivnet/%s`, Version))
+
+	client, ok := ctx.Value(exchangeClientKey{}).(*http.Client)
+	if !ok {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newAPIError(req, resp)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	token := struct {
+		AccessToken string `json:"access_token"`
+	}{}
+	err = json.Unmarshal(body, &token)
+	if err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("no access token in the response from %s", requestUrl)
+	}
+	return token.AccessToken, nil
+}
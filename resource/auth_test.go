@@ -0,0 +1,182 @@
+package resource_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cfmobile/gopivnet/resource"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Authenticator", func() {
+	var server *ghttp.Server
+
+	refreshToken := "0123456789abcdef0123456789abcdef-r"
+	products := `{"products":[{"id":60,"slug":"elastic-runtime"}]}`
+
+	exchange := func(accessToken string) http.HandlerFunc {
+		return ghttp.CombineHandlers(
+			ghttp.VerifyRequest("POST", "/api/v2/authentication/access_tokens"),
+			ghttp.VerifyJSON(`{"refresh_token":"`+refreshToken+`"}`),
+			ghttp.RespondWith(http.StatusOK, `{"access_token":"`+accessToken+`"}`),
+		)
+	}
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("sends legacy tokens as they are", func() {
+		auth := resource.NewAuthenticator(server.URL(), "abcdefghijklmnopqrst")
+
+		authorization, err := auth.Authorization(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authorization).To(Equal("Token abcdefghijklmnopqrst"))
+		Expect(auth.Expire(authorization)).To(BeFalse())
+	})
+
+	It("exchanges a refresh token for an access token once", func() {
+		server.AppendHandlers(
+			exchange("access-1"),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/api/v2/products"),
+				ghttp.VerifyHeaderKV("Authorization", "Bearer access-1"),
+				ghttp.RespondWith(http.StatusOK, products),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/api/v2/products"),
+				ghttp.VerifyHeaderKV("Authorization", "Bearer access-1"),
+				ghttp.RespondWith(http.StatusOK, products),
+			),
+		)
+		req := resource.NewRequester(server.URL(), refreshToken)
+
+		_, err := req.GetProducts()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = req.GetProducts()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(server.ReceivedRequests()).To(HaveLen(3))
+	})
+
+	It("renews an expired access token and sends the request again", func() {
+		server.AppendHandlers(
+			exchange("access-1"),
+			ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("Authorization", "Bearer access-1"),
+				ghttp.RespondWith(http.StatusUnauthorized, ""),
+			),
+			exchange("access-2"),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PATCH", "/api/v2/products/my-prod/releases/321/add_product_file"),
+				ghttp.VerifyHeaderKV("Authorization", "Bearer access-2"),
+				ghttp.VerifyJSON(`{"product_file":{"id":500}}`),
+				ghttp.RespondWith(http.StatusNoContent, ""),
+			),
+		)
+		req := resource.NewRequester(server.URL(), refreshToken,
+			resource.WithRetryPolicy(resource.RetryPolicy{MaxAttempts: 1}),
+		)
+
+		Expect(req.AddProductFile("my-prod", 321, 500)).To(Succeed())
+	})
+
+	It("renews the access token only once per request", func() {
+		server.AppendHandlers(
+			exchange("access-1"),
+			ghttp.RespondWith(http.StatusUnauthorized, ""),
+			exchange("access-2"),
+			ghttp.RespondWith(http.StatusUnauthorized, ""),
+		)
+		req := resource.NewRequester(server.URL(), refreshToken)
+
+		_, err := req.GetProducts()
+		Expect(errors.Is(err, resource.ErrUnauthorized)).To(BeTrue())
+		Expect(server.ReceivedRequests()).To(HaveLen(4))
+	})
+
+	It("doesn't renew legacy tokens", func() {
+		server.AppendHandlers(ghttp.RespondWith(http.StatusUnauthorized, ""))
+		req := resource.NewRequester(server.URL(), "token")
+
+		_, err := req.GetProducts()
+		Expect(errors.Is(err, resource.ErrUnauthorized)).To(BeTrue())
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("returns an error if the refresh token is rejected", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/api/v2/authentication/access_tokens"),
+				ghttp.RespondWith(http.StatusUnauthorized, `{"message":"invalid refresh token"}`),
+			),
+		)
+		req := resource.NewRequester(server.URL(), refreshToken)
+
+		_, err := req.GetProducts()
+		Expect(errors.Is(err, resource.ErrUnauthorized)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("invalid refresh token"))
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("tells refresh tokens from legacy tokens by their format", func() {
+		server.AppendHandlers(exchange("access-1"))
+
+		authorization, err := resource.NewAuthenticator(server.URL(), refreshToken).Authorization(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authorization).To(Equal("Bearer access-1"))
+
+		authorization, err = resource.NewAuthenticator(server.URL(), "0123456789abcdef0123456789abcdef").Authorization(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authorization).To(Equal("Token 0123456789abcdef0123456789abcdef"))
+	})
+
+	It("exchanges a shared refresh token with the client of each requester", func() {
+		server.AppendHandlers(
+			exchange("access-1"),
+			ghttp.RespondWith(http.StatusOK, products),
+		)
+		auth := resource.RefreshToken(server.URL(), refreshToken)
+		transport := &countingTransport{}
+		req := resource.NewRequester(server.URL(), "", resource.WithAuthenticator(auth), resource.WithHttpClient(&http.Client{Transport: transport}))
+
+		_, err := req.GetProducts()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(transport.requests).To(Equal(2))
+	})
+
+	It("limits the exchange to the request timeout", func() {
+		server.AppendHandlers(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		})
+		req := resource.NewRequester(server.URL(), refreshToken,
+			resource.WithRequestTimeout(20*time.Millisecond),
+			resource.WithRetryPolicy(resource.RetryPolicy{MaxAttempts: 1}),
+		)
+
+		_, err := req.GetProducts()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Timeout"))
+	})
+
+	It("uses the authenticator option over the token", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("Authorization", "Token other"),
+				ghttp.RespondWith(http.StatusOK, products),
+			),
+		)
+		req := resource.NewRequester(server.URL(), "token", resource.WithAuthenticator(resource.LegacyToken("other")))
+
+		_, err := req.GetProducts()
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,66 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type ReleaseDependencies struct {
+	Dependencies []ReleaseDependency `json:"dependencies"`
+}
+
+// ReleaseDependency is a release of another product, typically a stemcell,
+// that the release needs to be installed.
+type ReleaseDependency struct {
+	Release DependentRelease `json:"release"`
+}
+
+type DependentRelease struct {
+	Id      int            `json:"id"`
+	Version string         `json:"version"`
+	Product ProductSummary `json:"product"`
+}
+
+type UpgradePaths struct {
+	UpgradePaths []UpgradePath `json:"upgrade_paths"`
+}
+
+// UpgradePath is an earlier release of the same product that can be upgraded
+// to the release directly.
+type UpgradePath struct {
+	Id      int    `json:"id"`
+	Version string `json:"version"`
+}
+
+func (p *PivnetRequester) GetReleaseDependencies(productSlug string, releaseId int) (*ReleaseDependencies, error) {
+	return p.GetReleaseDependenciesContext(context.Background(), productSlug, releaseId)
+}
+
+func (p *PivnetRequester) GetReleaseDependenciesContext(ctx context.Context, productSlug string, releaseId int) (*ReleaseDependencies, error) {
+	requestUrl := fmt.Sprintf("%s/api/v2/products/%s/releases/%d/dependencies", p.pivnetUrl, productSlug, releaseId)
+	req, _ := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
+
+	dependencies := ReleaseDependencies{}
+	err := p.getJSON(req, &dependencies)
+	if err != nil {
+		return nil, err
+	}
+	return &dependencies, nil
+}
+
+func (p *PivnetRequester) GetUpgradePaths(productSlug string, releaseId int) (*UpgradePaths, error) {
+	return p.GetUpgradePathsContext(context.Background(), productSlug, releaseId)
+}
+
+func (p *PivnetRequester) GetUpgradePathsContext(ctx context.Context, productSlug string, releaseId int) (*UpgradePaths, error) {
+	requestUrl := fmt.Sprintf("%s/api/v2/products/%s/releases/%d/upgrade_paths", p.pivnetUrl, productSlug, releaseId)
+	req, _ := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
+
+	upgradePaths := UpgradePaths{}
+	err := p.getJSON(req, &upgradePaths)
+	if err != nil {
+		return nil, err
+	}
+	return &upgradePaths, nil
+}
@@ -0,0 +1,72 @@
+package resource_test
+
+import (
+	"net/http"
+
+	"github.com/cfmobile/gopivnet/resource"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Dependencies", func() {
+	var (
+		req    resource.ReleaseRequester
+		server *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		req = resource.NewRequester(server.URL(), "token")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("GetReleaseDependencies", func() {
+		It("returns the releases the release depends on", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/products/my-prod/releases/321/dependencies"),
+					ghttp.VerifyHeaderKV("Authorization", "Token token"),
+					ghttp.RespondWith(http.StatusOK, `{"dependencies":[{"release":{"id":12,"version":"3468.21","product":{"id":82,"slug":"stemcells","name":"Stemcells"}}}]}`),
+				),
+			)
+
+			dependencies, err := req.GetReleaseDependencies("my-prod", 321)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dependencies.Dependencies).To(HaveLen(1))
+			Expect(dependencies.Dependencies[0].Release.Id).To(Equal(12))
+			Expect(dependencies.Dependencies[0].Release.Version).To(Equal("3468.21"))
+			Expect(dependencies.Dependencies[0].Release.Product.Slug).To(Equal("stemcells"))
+		})
+
+		It("returns an error for an unknown release", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, `{"status":404,"message":"not found"}`))
+
+			_, err := req.GetReleaseDependencies("my-prod", 321)
+			Expect(err).To(MatchError(resource.ErrNotFound))
+		})
+	})
+
+	Context("GetUpgradePaths", func() {
+		It("returns the releases that can be upgraded to the release", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/products/my-prod/releases/321/upgrade_paths"),
+					ghttp.VerifyHeaderKV("Authorization", "Token token"),
+					ghttp.RespondWith(http.StatusOK, `{"upgrade_paths":[{"id":300,"version":"1.7.2"},{"id":310,"version":"1.8.0"}]}`),
+				),
+			)
+
+			upgradePaths, err := req.GetUpgradePaths("my-prod", 321)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(upgradePaths.UpgradePaths).To(Equal([]resource.UpgradePath{
+				{Id: 300, Version: "1.7.2"},
+				{Id: 310, Version: "1.8.0"},
+			}))
+		})
+	})
+})
@@ -0,0 +1,75 @@
+package resource
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrUnauthorized = errors.New("not authorized, check the pivnet token")
+	ErrNotFound     = errors.New("not found")
+	ErrEulaRequired = errors.New("the eula must be accepted")
+	ErrRateLimited  = errors.New("rate limited by pivotal network")
+)
+
+// APIError is returned for every unexpected response from Pivotal Network.
+// It unwraps to one of the Err* values above when the status code has a
+// specific meaning, so callers can use errors.Is as well as errors.As.
+type APIError struct {
+	StatusCode int
+	Method     string
+	URL        string
+	Message    string
+	Errors     []string
+}
+
+type errorBody struct {
+	Status  int      `json:"status"`
+	Message string   `json:"message"`
+	Errors  []string `json:"errors"`
+}
+
+func newAPIError(req *http.Request, resp *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     req.Method,
+		URL:        req.URL.String(),
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	parsed := errorBody{}
+	if json.Unmarshal(body, &parsed) == nil {
+		apiErr.Message = parsed.Message
+		apiErr.Errors = parsed.Errors
+	}
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if len(e.Errors) > 0 {
+		msg += " (" + strings.Join(e.Errors, "; ") + ")"
+	}
+	return msg
+}
+
+func (e *APIError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return ErrNotFound
+	case RequireEula:
+		return ErrEulaRequired
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package resource
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/user"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// PendingEula is a eula Pivotal Network requires to be accepted before a file
+// of Release can be downloaded.
+type PendingEula struct {
+	ProductSlug string
+	Release     Release
+	Eula        Eula
+}
+
+// EulaPolicy decides whether a pending eula is accepted on the user's behalf.
+type EulaPolicy interface {
+	AcceptEula(ctx context.Context, eula PendingEula) (bool, error)
+}
+
+type EulaPolicyFunc func(ctx context.Context, eula PendingEula) (bool, error)
+
+func (f EulaPolicyFunc) AcceptEula(ctx context.Context, eula PendingEula) (bool, error) {
+	return f(ctx, eula)
+}
+
+type autoAcceptEula struct{}
+
+func (autoAcceptEula) AcceptEula(context.Context, PendingEula) (bool, error) {
+	return true, nil
+}
+
+var (
+	// AutoAcceptEula accepts every eula. It is the default policy and the only
+	// one that doesn't look the eula up before accepting it, unless an audit
+	// log is kept.
+	AutoAcceptEula EulaPolicy = autoAcceptEula{}
+
+	// RejectEula never accepts a eula, so downloads needing one fail with
+	// ErrEulaRequired.
+	RejectEula EulaPolicy = EulaPolicyFunc(func(context.Context, PendingEula) (bool, error) {
+		return false, nil
+	})
+)
+
+// AcceptEulas accepts only the eulas with one of the given slugs.
+func AcceptEulas(slugs ...string) EulaPolicy {
+	return EulaPolicyFunc(func(_ context.Context, eula PendingEula) (bool, error) {
+		for _, slug := range slugs {
+			if slug == eula.Eula.Slug {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+// PromptForEula shows the eula on out and accepts it if the answer read from
+// in starts with "y".
+func PromptForEula(in io.Reader, out io.Writer) EulaPolicy {
+	reader := bufio.NewReader(in)
+	return EulaPolicyFunc(func(_ context.Context, eula PendingEula) (bool, error) {
+		fmt.Fprintf(out, "%s %s requires accepting the %q eula (%s):\n\n%s\n\n",
+			eula.ProductSlug, eula.Release.Version, eula.Eula.Name, eula.Eula.Slug, eula.Eula.Text())
+		fmt.Fprint(out, "Accept? [y/N] ")
+
+		answer, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		return strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y"), nil
+	})
+}
+
+// WithEulaPolicy sets the policy consulted when a download needs a eula to be
+// accepted. The default is AutoAcceptEula.
+func WithEulaPolicy(policy EulaPolicy) ClientOption {
+	return func(c *requesterConfig) {
+		c.eulaPolicy = policy
+	}
+}
+
+// WithEulaAuditLog writes an EulaAcceptance as a line of JSON to w every time
+// a eula is accepted.
+func WithEulaAuditLog(w io.Writer) ClientOption {
+	return func(c *requesterConfig) {
+		c.eulaAuditLog = w
+	}
+}
+
+// EulaAcceptance is a line of the eula audit log.
+type EulaAcceptance struct {
+	Time           time.Time `json:"time"`
+	User           string    `json:"user"`
+	Product        string    `json:"product"`
+	ReleaseId      int       `json:"release_id"`
+	ReleaseVersion string    `json:"release_version"`
+	EulaSlug       string    `json:"eula_slug"`
+	EulaName       string    `json:"eula_name"`
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+var blankLines = regexp.MustCompile(`\n\s*\n\s*`)
+
+// Text returns the content of the eula with its html markup removed.
+func (e *Eula) Text() string {
+	text := strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p>", "\n\n").Replace(e.Content)
+	text = html.UnescapeString(htmlTag.ReplaceAllString(text, ""))
+	return strings.TrimSpace(blankLines.ReplaceAllString(text, "\n\n"))
+}
+
+func (p *PivnetRequester) GetEula(slug string) (*Eula, error) {
+	return p.GetEulaContext(context.Background(), slug)
+}
+
+// GetEulaContext returns the eula with the given slug, including its content.
+func (p *PivnetRequester) GetEulaContext(ctx context.Context, slug string) (*Eula, error) {
+	requestUrl := fmt.Sprintf("%s/api/v2/eulas/%s", p.pivnetUrl, slug)
+	req, _ := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
+
+	eula := Eula{}
+	err := p.getJSON(req, &eula)
+	if err != nil {
+		return nil, err
+	}
+	return &eula, nil
+}
+
+// approveEula asks the eula policy about the eula behind agreementUrl and
+// accepts it if the policy agrees.
+func (p *PivnetRequester) approveEula(ctx context.Context, agreementUrl string) error {
+	if p.eulaPolicy == AutoAcceptEula && p.eulaAuditLog == nil {
+		return p.acceptEula(ctx, agreementUrl)
+	}
+
+	pending, err := p.pendingEula(ctx, agreementUrl)
+	if err != nil {
+		return fmt.Errorf("Unable to look up the eula: %w", err)
+	}
+
+	accepted, err := p.eulaPolicy.AcceptEula(ctx, *pending)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return fmt.Errorf("%w: the %q eula of %s %s was not accepted",
+			ErrEulaRequired, pending.Eula.Slug, pending.ProductSlug, pending.Release.Version)
+	}
+
+	err = p.acceptEula(ctx, agreementUrl)
+	if err != nil {
+		return err
+	}
+
+	return p.auditEula(pending)
+}
+
+// pendingEula looks up the release a eula acceptance url belongs to, e.g.
+// .../products/<slug>/releases/<id>/eula_acceptance, and its eula.
+func (p *PivnetRequester) pendingEula(ctx context.Context, agreementUrl string) (*PendingEula, error) {
+	releaseUrl := strings.TrimSuffix(agreementUrl, "/eula_acceptance")
+	req, _ := http.NewRequestWithContext(ctx, "GET", releaseUrl, nil)
+
+	pending := &PendingEula{ProductSlug: productSlug(releaseUrl)}
+	err := p.getJSON(req, &pending.Release)
+	if err != nil {
+		return nil, err
+	}
+
+	pending.Eula = pending.Release.Eula
+	if pending.Eula.Slug != "" {
+		eula, err := p.GetEulaContext(ctx, pending.Eula.Slug)
+		if err != nil {
+			return nil, err
+		}
+		pending.Eula = *eula
+	}
+	return pending, nil
+}
+
+func (p *PivnetRequester) auditEula(pending *PendingEula) error {
+	if p.eulaAuditLog == nil {
+		return nil
+	}
+
+	line, _ := json.Marshal(EulaAcceptance{
+		Time:           time.Now().UTC(),
+		User:           currentUser(),
+		Product:        pending.ProductSlug,
+		ReleaseId:      pending.Release.Id,
+		ReleaseVersion: pending.Release.Version,
+		EulaSlug:       pending.Eula.Slug,
+		EulaName:       pending.Eula.Name,
+	})
+
+	_, err := p.eulaAuditLog.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("Unable to write the eula audit log: %w", err)
+	}
+	return nil
+}
+
+func (p *PivnetRequester) getJSON(req *http.Request, v interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(req, resp)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	return json.Unmarshal(body, v)
+}
+
+func productSlug(releaseUrl string) string {
+	tokens := strings.Split(releaseUrl, "/")
+	for index, token := range tokens {
+		if token == "products" && index+1 < len(tokens) {
+			return tokens[index+1]
+		}
+	}
+	return ""
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -2,12 +2,22 @@
 package fakes
 
 import (
+	"context"
+	"io"
 	"sync"
 
 	"github.com/cfmobile/gopivnet/resource"
 )
 
 type FakeReleaseRequester struct {
+	GetProductsStub        func() (*resource.Products, error)
+	getProductsMutex       sync.RWMutex
+	getProductsArgsForCall []struct {
+	}
+	getProductsReturns struct {
+		result1 *resource.Products
+		result2 error
+	}
 	GetProductStub        func(productName string) (*resource.Product, error)
 	getProductMutex       sync.RWMutex
 	getProductArgsForCall []struct {
@@ -35,6 +45,300 @@ type FakeReleaseRequester struct {
 		result1 string
 		result2 error
 	}
+	GetEulaStub        func(slug string) (*resource.Eula, error)
+	getEulaMutex       sync.RWMutex
+	getEulaArgsForCall []struct {
+		slug string
+	}
+	getEulaReturns struct {
+		result1 *resource.Eula
+		result2 error
+	}
+	CreateReleaseStub        func(productSlug string, release resource.NewRelease) (*resource.Release, error)
+	createReleaseMutex       sync.RWMutex
+	createReleaseArgsForCall []struct {
+		productSlug string
+		release     resource.NewRelease
+	}
+	createReleaseReturns struct {
+		result1 *resource.Release
+		result2 error
+	}
+	UpdateReleaseAvailabilityStub        func(productSlug string, releaseId int, availability string, userGroupIds []int) error
+	updateReleaseAvailabilityMutex       sync.RWMutex
+	updateReleaseAvailabilityArgsForCall []struct {
+		productSlug  string
+		releaseId    int
+		availability string
+		userGroupIds []int
+	}
+	updateReleaseAvailabilityReturns struct {
+		result1 error
+	}
+	DeleteReleaseStub        func(productSlug string, releaseId int) error
+	deleteReleaseMutex       sync.RWMutex
+	deleteReleaseArgsForCall []struct {
+		productSlug string
+		releaseId   int
+	}
+	deleteReleaseReturns struct {
+		result1 error
+	}
+	GetUploadCredentialsStub        func(productSlug string) (*resource.UploadCredentials, error)
+	getUploadCredentialsMutex       sync.RWMutex
+	getUploadCredentialsArgsForCall []struct {
+		productSlug string
+	}
+	getUploadCredentialsReturns struct {
+		result1 *resource.UploadCredentials
+		result2 error
+	}
+	UploadFileStub        func(productSlug string, objectKey string, content io.Reader) error
+	uploadFileMutex       sync.RWMutex
+	uploadFileArgsForCall []struct {
+		productSlug string
+		objectKey   string
+		content     io.Reader
+	}
+	uploadFileReturns struct {
+		result1 error
+	}
+	CreateProductFileStub        func(productSlug string, productFile resource.NewProductFile) (*resource.ProductFile, error)
+	createProductFileMutex       sync.RWMutex
+	createProductFileArgsForCall []struct {
+		productSlug string
+		productFile resource.NewProductFile
+	}
+	createProductFileReturns struct {
+		result1 *resource.ProductFile
+		result2 error
+	}
+	AddProductFileStub        func(productSlug string, releaseId int, productFileId int) error
+	addProductFileMutex       sync.RWMutex
+	addProductFileArgsForCall []struct {
+		productSlug   string
+		releaseId     int
+		productFileId int
+	}
+	addProductFileReturns struct {
+		result1 error
+	}
+	GetReleaseDependenciesStub        func(productSlug string, releaseId int) (*resource.ReleaseDependencies, error)
+	getReleaseDependenciesMutex       sync.RWMutex
+	getReleaseDependenciesArgsForCall []struct {
+		productSlug string
+		releaseId   int
+	}
+	getReleaseDependenciesReturns struct {
+		result1 *resource.ReleaseDependencies
+		result2 error
+	}
+	GetUpgradePathsStub        func(productSlug string, releaseId int) (*resource.UpgradePaths, error)
+	getUpgradePathsMutex       sync.RWMutex
+	getUpgradePathsArgsForCall []struct {
+		productSlug string
+		releaseId   int
+	}
+	getUpgradePathsReturns struct {
+		result1 *resource.UpgradePaths
+		result2 error
+	}
+	GetProductFileStub        func(productSlug string, releaseId int, productFileId int) (*resource.ProductFile, error)
+	getProductFileMutex       sync.RWMutex
+	getProductFileArgsForCall []struct {
+		productSlug   string
+		releaseId     int
+		productFileId int
+	}
+	getProductFileReturns struct {
+		result1 *resource.ProductFile
+		result2 error
+	}
+	GetProductsContextStub        func(ctx context.Context) (*resource.Products, error)
+	getProductsContextMutex       sync.RWMutex
+	getProductsContextArgsForCall []struct {
+		ctx context.Context
+	}
+	getProductsContextReturns struct {
+		result1 *resource.Products
+		result2 error
+	}
+	GetProductContextStub        func(ctx context.Context, productName string) (*resource.Product, error)
+	getProductContextMutex       sync.RWMutex
+	getProductContextArgsForCall []struct {
+		ctx         context.Context
+		productName string
+	}
+	getProductContextReturns struct {
+		result1 *resource.Product
+		result2 error
+	}
+	GetProductFilesContextStub        func(ctx context.Context, release resource.Release) (*resource.ProductFiles, error)
+	getProductFilesContextMutex       sync.RWMutex
+	getProductFilesContextArgsForCall []struct {
+		ctx     context.Context
+		release resource.Release
+	}
+	getProductFilesContextReturns struct {
+		result1 *resource.ProductFiles
+		result2 error
+	}
+	GetProductDownloadUrlContextStub        func(ctx context.Context, productFile *resource.ProductFile) (string, error)
+	getProductDownloadUrlContextMutex       sync.RWMutex
+	getProductDownloadUrlContextArgsForCall []struct {
+		ctx         context.Context
+		productFile *resource.ProductFile
+	}
+	getProductDownloadUrlContextReturns struct {
+		result1 string
+		result2 error
+	}
+	GetEulaContextStub        func(ctx context.Context, slug string) (*resource.Eula, error)
+	getEulaContextMutex       sync.RWMutex
+	getEulaContextArgsForCall []struct {
+		ctx  context.Context
+		slug string
+	}
+	getEulaContextReturns struct {
+		result1 *resource.Eula
+		result2 error
+	}
+	CreateReleaseContextStub        func(ctx context.Context, productSlug string, release resource.NewRelease) (*resource.Release, error)
+	createReleaseContextMutex       sync.RWMutex
+	createReleaseContextArgsForCall []struct {
+		ctx         context.Context
+		productSlug string
+		release     resource.NewRelease
+	}
+	createReleaseContextReturns struct {
+		result1 *resource.Release
+		result2 error
+	}
+	UpdateReleaseAvailabilityContextStub        func(ctx context.Context, productSlug string, releaseId int, availability string, userGroupIds []int) error
+	updateReleaseAvailabilityContextMutex       sync.RWMutex
+	updateReleaseAvailabilityContextArgsForCall []struct {
+		ctx          context.Context
+		productSlug  string
+		releaseId    int
+		availability string
+		userGroupIds []int
+	}
+	updateReleaseAvailabilityContextReturns struct {
+		result1 error
+	}
+	DeleteReleaseContextStub        func(ctx context.Context, productSlug string, releaseId int) error
+	deleteReleaseContextMutex       sync.RWMutex
+	deleteReleaseContextArgsForCall []struct {
+		ctx         context.Context
+		productSlug string
+		releaseId   int
+	}
+	deleteReleaseContextReturns struct {
+		result1 error
+	}
+	GetUploadCredentialsContextStub        func(ctx context.Context, productSlug string) (*resource.UploadCredentials, error)
+	getUploadCredentialsContextMutex       sync.RWMutex
+	getUploadCredentialsContextArgsForCall []struct {
+		ctx         context.Context
+		productSlug string
+	}
+	getUploadCredentialsContextReturns struct {
+		result1 *resource.UploadCredentials
+		result2 error
+	}
+	UploadFileContextStub        func(ctx context.Context, productSlug string, objectKey string, content io.Reader) error
+	uploadFileContextMutex       sync.RWMutex
+	uploadFileContextArgsForCall []struct {
+		ctx         context.Context
+		productSlug string
+		objectKey   string
+		content     io.Reader
+	}
+	uploadFileContextReturns struct {
+		result1 error
+	}
+	CreateProductFileContextStub        func(ctx context.Context, productSlug string, productFile resource.NewProductFile) (*resource.ProductFile, error)
+	createProductFileContextMutex       sync.RWMutex
+	createProductFileContextArgsForCall []struct {
+		ctx         context.Context
+		productSlug string
+		productFile resource.NewProductFile
+	}
+	createProductFileContextReturns struct {
+		result1 *resource.ProductFile
+		result2 error
+	}
+	AddProductFileContextStub        func(ctx context.Context, productSlug string, releaseId int, productFileId int) error
+	addProductFileContextMutex       sync.RWMutex
+	addProductFileContextArgsForCall []struct {
+		ctx           context.Context
+		productSlug   string
+		releaseId     int
+		productFileId int
+	}
+	addProductFileContextReturns struct {
+		result1 error
+	}
+	GetReleaseDependenciesContextStub        func(ctx context.Context, productSlug string, releaseId int) (*resource.ReleaseDependencies, error)
+	getReleaseDependenciesContextMutex       sync.RWMutex
+	getReleaseDependenciesContextArgsForCall []struct {
+		ctx         context.Context
+		productSlug string
+		releaseId   int
+	}
+	getReleaseDependenciesContextReturns struct {
+		result1 *resource.ReleaseDependencies
+		result2 error
+	}
+	GetUpgradePathsContextStub        func(ctx context.Context, productSlug string, releaseId int) (*resource.UpgradePaths, error)
+	getUpgradePathsContextMutex       sync.RWMutex
+	getUpgradePathsContextArgsForCall []struct {
+		ctx         context.Context
+		productSlug string
+		releaseId   int
+	}
+	getUpgradePathsContextReturns struct {
+		result1 *resource.UpgradePaths
+		result2 error
+	}
+	GetProductFileContextStub        func(ctx context.Context, productSlug string, releaseId int, productFileId int) (*resource.ProductFile, error)
+	getProductFileContextMutex       sync.RWMutex
+	getProductFileContextArgsForCall []struct {
+		ctx           context.Context
+		productSlug   string
+		releaseId     int
+		productFileId int
+	}
+	getProductFileContextReturns struct {
+		result1 *resource.ProductFile
+		result2 error
+	}
+}
+
+func (fake *FakeReleaseRequester) GetProducts() (*resource.Products, error) {
+	fake.getProductsMutex.Lock()
+	fake.getProductsArgsForCall = append(fake.getProductsArgsForCall, struct {
+	}{})
+	fake.getProductsMutex.Unlock()
+	if fake.GetProductsStub != nil {
+		return fake.GetProductsStub()
+	} else {
+		return fake.getProductsReturns.result1, fake.getProductsReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) GetProductsCallCount() int {
+	fake.getProductsMutex.RLock()
+	defer fake.getProductsMutex.RUnlock()
+	return len(fake.getProductsArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) GetProductsReturns(result1 *resource.Products, result2 error) {
+	fake.GetProductsStub = nil
+	fake.getProductsReturns = struct {
+		result1 *resource.Products
+		result2 error
+	}{result1, result2}
 }
 
 func (fake *FakeReleaseRequester) GetProduct(productName string) (*resource.Product, error) {
@@ -136,4 +440,896 @@ func (fake *FakeReleaseRequester) GetProductDownloadUrlReturns(result1 string, r
 	}{result1, result2}
 }
 
+func (fake *FakeReleaseRequester) GetEula(slug string) (*resource.Eula, error) {
+	fake.getEulaMutex.Lock()
+	fake.getEulaArgsForCall = append(fake.getEulaArgsForCall, struct {
+		slug string
+	}{slug})
+	fake.getEulaMutex.Unlock()
+	if fake.GetEulaStub != nil {
+		return fake.GetEulaStub(slug)
+	} else {
+		return fake.getEulaReturns.result1, fake.getEulaReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) GetEulaCallCount() int {
+	fake.getEulaMutex.RLock()
+	defer fake.getEulaMutex.RUnlock()
+	return len(fake.getEulaArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) GetEulaArgsForCall(i int) string {
+	fake.getEulaMutex.RLock()
+	defer fake.getEulaMutex.RUnlock()
+	return fake.getEulaArgsForCall[i].slug
+}
+
+func (fake *FakeReleaseRequester) GetEulaReturns(result1 *resource.Eula, result2 error) {
+	fake.GetEulaStub = nil
+	fake.getEulaReturns = struct {
+		result1 *resource.Eula
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) CreateRelease(productSlug string, release resource.NewRelease) (*resource.Release, error) {
+	fake.createReleaseMutex.Lock()
+	fake.createReleaseArgsForCall = append(fake.createReleaseArgsForCall, struct {
+		productSlug string
+		release     resource.NewRelease
+	}{productSlug, release})
+	fake.createReleaseMutex.Unlock()
+	if fake.CreateReleaseStub != nil {
+		return fake.CreateReleaseStub(productSlug, release)
+	} else {
+		return fake.createReleaseReturns.result1, fake.createReleaseReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) CreateReleaseCallCount() int {
+	fake.createReleaseMutex.RLock()
+	defer fake.createReleaseMutex.RUnlock()
+	return len(fake.createReleaseArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) CreateReleaseArgsForCall(i int) (string, resource.NewRelease) {
+	fake.createReleaseMutex.RLock()
+	defer fake.createReleaseMutex.RUnlock()
+	return fake.createReleaseArgsForCall[i].productSlug, fake.createReleaseArgsForCall[i].release
+}
+
+func (fake *FakeReleaseRequester) CreateReleaseReturns(result1 *resource.Release, result2 error) {
+	fake.CreateReleaseStub = nil
+	fake.createReleaseReturns = struct {
+		result1 *resource.Release
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) UpdateReleaseAvailability(productSlug string, releaseId int, availability string, userGroupIds []int) error {
+	fake.updateReleaseAvailabilityMutex.Lock()
+	fake.updateReleaseAvailabilityArgsForCall = append(fake.updateReleaseAvailabilityArgsForCall, struct {
+		productSlug  string
+		releaseId    int
+		availability string
+		userGroupIds []int
+	}{productSlug, releaseId, availability, userGroupIds})
+	fake.updateReleaseAvailabilityMutex.Unlock()
+	if fake.UpdateReleaseAvailabilityStub != nil {
+		return fake.UpdateReleaseAvailabilityStub(productSlug, releaseId, availability, userGroupIds)
+	} else {
+		return fake.updateReleaseAvailabilityReturns.result1
+	}
+}
+
+func (fake *FakeReleaseRequester) UpdateReleaseAvailabilityCallCount() int {
+	fake.updateReleaseAvailabilityMutex.RLock()
+	defer fake.updateReleaseAvailabilityMutex.RUnlock()
+	return len(fake.updateReleaseAvailabilityArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) UpdateReleaseAvailabilityArgsForCall(i int) (string, int, string, []int) {
+	fake.updateReleaseAvailabilityMutex.RLock()
+	defer fake.updateReleaseAvailabilityMutex.RUnlock()
+	return fake.updateReleaseAvailabilityArgsForCall[i].productSlug, fake.updateReleaseAvailabilityArgsForCall[i].releaseId, fake.updateReleaseAvailabilityArgsForCall[i].availability, fake.updateReleaseAvailabilityArgsForCall[i].userGroupIds
+}
+
+func (fake *FakeReleaseRequester) UpdateReleaseAvailabilityReturns(result1 error) {
+	fake.UpdateReleaseAvailabilityStub = nil
+	fake.updateReleaseAvailabilityReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeReleaseRequester) DeleteRelease(productSlug string, releaseId int) error {
+	fake.deleteReleaseMutex.Lock()
+	fake.deleteReleaseArgsForCall = append(fake.deleteReleaseArgsForCall, struct {
+		productSlug string
+		releaseId   int
+	}{productSlug, releaseId})
+	fake.deleteReleaseMutex.Unlock()
+	if fake.DeleteReleaseStub != nil {
+		return fake.DeleteReleaseStub(productSlug, releaseId)
+	} else {
+		return fake.deleteReleaseReturns.result1
+	}
+}
+
+func (fake *FakeReleaseRequester) DeleteReleaseCallCount() int {
+	fake.deleteReleaseMutex.RLock()
+	defer fake.deleteReleaseMutex.RUnlock()
+	return len(fake.deleteReleaseArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) DeleteReleaseArgsForCall(i int) (string, int) {
+	fake.deleteReleaseMutex.RLock()
+	defer fake.deleteReleaseMutex.RUnlock()
+	return fake.deleteReleaseArgsForCall[i].productSlug, fake.deleteReleaseArgsForCall[i].releaseId
+}
+
+func (fake *FakeReleaseRequester) DeleteReleaseReturns(result1 error) {
+	fake.DeleteReleaseStub = nil
+	fake.deleteReleaseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeReleaseRequester) GetUploadCredentials(productSlug string) (*resource.UploadCredentials, error) {
+	fake.getUploadCredentialsMutex.Lock()
+	fake.getUploadCredentialsArgsForCall = append(fake.getUploadCredentialsArgsForCall, struct {
+		productSlug string
+	}{productSlug})
+	fake.getUploadCredentialsMutex.Unlock()
+	if fake.GetUploadCredentialsStub != nil {
+		return fake.GetUploadCredentialsStub(productSlug)
+	} else {
+		return fake.getUploadCredentialsReturns.result1, fake.getUploadCredentialsReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) GetUploadCredentialsCallCount() int {
+	fake.getUploadCredentialsMutex.RLock()
+	defer fake.getUploadCredentialsMutex.RUnlock()
+	return len(fake.getUploadCredentialsArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) GetUploadCredentialsArgsForCall(i int) string {
+	fake.getUploadCredentialsMutex.RLock()
+	defer fake.getUploadCredentialsMutex.RUnlock()
+	return fake.getUploadCredentialsArgsForCall[i].productSlug
+}
+
+func (fake *FakeReleaseRequester) GetUploadCredentialsReturns(result1 *resource.UploadCredentials, result2 error) {
+	fake.GetUploadCredentialsStub = nil
+	fake.getUploadCredentialsReturns = struct {
+		result1 *resource.UploadCredentials
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) UploadFile(productSlug string, objectKey string, content io.Reader) error {
+	fake.uploadFileMutex.Lock()
+	fake.uploadFileArgsForCall = append(fake.uploadFileArgsForCall, struct {
+		productSlug string
+		objectKey   string
+		content     io.Reader
+	}{productSlug, objectKey, content})
+	fake.uploadFileMutex.Unlock()
+	if fake.UploadFileStub != nil {
+		return fake.UploadFileStub(productSlug, objectKey, content)
+	} else {
+		return fake.uploadFileReturns.result1
+	}
+}
+
+func (fake *FakeReleaseRequester) UploadFileCallCount() int {
+	fake.uploadFileMutex.RLock()
+	defer fake.uploadFileMutex.RUnlock()
+	return len(fake.uploadFileArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) UploadFileArgsForCall(i int) (string, string, io.Reader) {
+	fake.uploadFileMutex.RLock()
+	defer fake.uploadFileMutex.RUnlock()
+	return fake.uploadFileArgsForCall[i].productSlug, fake.uploadFileArgsForCall[i].objectKey, fake.uploadFileArgsForCall[i].content
+}
+
+func (fake *FakeReleaseRequester) UploadFileReturns(result1 error) {
+	fake.UploadFileStub = nil
+	fake.uploadFileReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeReleaseRequester) CreateProductFile(productSlug string, productFile resource.NewProductFile) (*resource.ProductFile, error) {
+	fake.createProductFileMutex.Lock()
+	fake.createProductFileArgsForCall = append(fake.createProductFileArgsForCall, struct {
+		productSlug string
+		productFile resource.NewProductFile
+	}{productSlug, productFile})
+	fake.createProductFileMutex.Unlock()
+	if fake.CreateProductFileStub != nil {
+		return fake.CreateProductFileStub(productSlug, productFile)
+	} else {
+		return fake.createProductFileReturns.result1, fake.createProductFileReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) CreateProductFileCallCount() int {
+	fake.createProductFileMutex.RLock()
+	defer fake.createProductFileMutex.RUnlock()
+	return len(fake.createProductFileArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) CreateProductFileArgsForCall(i int) (string, resource.NewProductFile) {
+	fake.createProductFileMutex.RLock()
+	defer fake.createProductFileMutex.RUnlock()
+	return fake.createProductFileArgsForCall[i].productSlug, fake.createProductFileArgsForCall[i].productFile
+}
+
+func (fake *FakeReleaseRequester) CreateProductFileReturns(result1 *resource.ProductFile, result2 error) {
+	fake.CreateProductFileStub = nil
+	fake.createProductFileReturns = struct {
+		result1 *resource.ProductFile
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) AddProductFile(productSlug string, releaseId int, productFileId int) error {
+	fake.addProductFileMutex.Lock()
+	fake.addProductFileArgsForCall = append(fake.addProductFileArgsForCall, struct {
+		productSlug   string
+		releaseId     int
+		productFileId int
+	}{productSlug, releaseId, productFileId})
+	fake.addProductFileMutex.Unlock()
+	if fake.AddProductFileStub != nil {
+		return fake.AddProductFileStub(productSlug, releaseId, productFileId)
+	} else {
+		return fake.addProductFileReturns.result1
+	}
+}
+
+func (fake *FakeReleaseRequester) AddProductFileCallCount() int {
+	fake.addProductFileMutex.RLock()
+	defer fake.addProductFileMutex.RUnlock()
+	return len(fake.addProductFileArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) AddProductFileArgsForCall(i int) (string, int, int) {
+	fake.addProductFileMutex.RLock()
+	defer fake.addProductFileMutex.RUnlock()
+	return fake.addProductFileArgsForCall[i].productSlug, fake.addProductFileArgsForCall[i].releaseId, fake.addProductFileArgsForCall[i].productFileId
+}
+
+func (fake *FakeReleaseRequester) AddProductFileReturns(result1 error) {
+	fake.AddProductFileStub = nil
+	fake.addProductFileReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeReleaseRequester) GetReleaseDependencies(productSlug string, releaseId int) (*resource.ReleaseDependencies, error) {
+	fake.getReleaseDependenciesMutex.Lock()
+	fake.getReleaseDependenciesArgsForCall = append(fake.getReleaseDependenciesArgsForCall, struct {
+		productSlug string
+		releaseId   int
+	}{productSlug, releaseId})
+	fake.getReleaseDependenciesMutex.Unlock()
+	if fake.GetReleaseDependenciesStub != nil {
+		return fake.GetReleaseDependenciesStub(productSlug, releaseId)
+	} else {
+		return fake.getReleaseDependenciesReturns.result1, fake.getReleaseDependenciesReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) GetReleaseDependenciesCallCount() int {
+	fake.getReleaseDependenciesMutex.RLock()
+	defer fake.getReleaseDependenciesMutex.RUnlock()
+	return len(fake.getReleaseDependenciesArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) GetReleaseDependenciesArgsForCall(i int) (string, int) {
+	fake.getReleaseDependenciesMutex.RLock()
+	defer fake.getReleaseDependenciesMutex.RUnlock()
+	return fake.getReleaseDependenciesArgsForCall[i].productSlug, fake.getReleaseDependenciesArgsForCall[i].releaseId
+}
+
+func (fake *FakeReleaseRequester) GetReleaseDependenciesReturns(result1 *resource.ReleaseDependencies, result2 error) {
+	fake.GetReleaseDependenciesStub = nil
+	fake.getReleaseDependenciesReturns = struct {
+		result1 *resource.ReleaseDependencies
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) GetUpgradePaths(productSlug string, releaseId int) (*resource.UpgradePaths, error) {
+	fake.getUpgradePathsMutex.Lock()
+	fake.getUpgradePathsArgsForCall = append(fake.getUpgradePathsArgsForCall, struct {
+		productSlug string
+		releaseId   int
+	}{productSlug, releaseId})
+	fake.getUpgradePathsMutex.Unlock()
+	if fake.GetUpgradePathsStub != nil {
+		return fake.GetUpgradePathsStub(productSlug, releaseId)
+	} else {
+		return fake.getUpgradePathsReturns.result1, fake.getUpgradePathsReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) GetUpgradePathsCallCount() int {
+	fake.getUpgradePathsMutex.RLock()
+	defer fake.getUpgradePathsMutex.RUnlock()
+	return len(fake.getUpgradePathsArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) GetUpgradePathsArgsForCall(i int) (string, int) {
+	fake.getUpgradePathsMutex.RLock()
+	defer fake.getUpgradePathsMutex.RUnlock()
+	return fake.getUpgradePathsArgsForCall[i].productSlug, fake.getUpgradePathsArgsForCall[i].releaseId
+}
+
+func (fake *FakeReleaseRequester) GetUpgradePathsReturns(result1 *resource.UpgradePaths, result2 error) {
+	fake.GetUpgradePathsStub = nil
+	fake.getUpgradePathsReturns = struct {
+		result1 *resource.UpgradePaths
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) GetProductFile(productSlug string, releaseId int, productFileId int) (*resource.ProductFile, error) {
+	fake.getProductFileMutex.Lock()
+	fake.getProductFileArgsForCall = append(fake.getProductFileArgsForCall, struct {
+		productSlug   string
+		releaseId     int
+		productFileId int
+	}{productSlug, releaseId, productFileId})
+	fake.getProductFileMutex.Unlock()
+	if fake.GetProductFileStub != nil {
+		return fake.GetProductFileStub(productSlug, releaseId, productFileId)
+	} else {
+		return fake.getProductFileReturns.result1, fake.getProductFileReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) GetProductFileCallCount() int {
+	fake.getProductFileMutex.RLock()
+	defer fake.getProductFileMutex.RUnlock()
+	return len(fake.getProductFileArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) GetProductFileArgsForCall(i int) (string, int, int) {
+	fake.getProductFileMutex.RLock()
+	defer fake.getProductFileMutex.RUnlock()
+	return fake.getProductFileArgsForCall[i].productSlug, fake.getProductFileArgsForCall[i].releaseId, fake.getProductFileArgsForCall[i].productFileId
+}
+
+func (fake *FakeReleaseRequester) GetProductFileReturns(result1 *resource.ProductFile, result2 error) {
+	fake.GetProductFileStub = nil
+	fake.getProductFileReturns = struct {
+		result1 *resource.ProductFile
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) GetProductsContext(ctx context.Context) (*resource.Products, error) {
+	fake.getProductsContextMutex.Lock()
+	fake.getProductsContextArgsForCall = append(fake.getProductsContextArgsForCall, struct {
+		ctx context.Context
+	}{ctx})
+	fake.getProductsContextMutex.Unlock()
+	if fake.GetProductsContextStub != nil {
+		return fake.GetProductsContextStub(ctx)
+	} else {
+		return fake.getProductsContextReturns.result1, fake.getProductsContextReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) GetProductsContextCallCount() int {
+	fake.getProductsContextMutex.RLock()
+	defer fake.getProductsContextMutex.RUnlock()
+	return len(fake.getProductsContextArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) GetProductsContextArgsForCall(i int) context.Context {
+	fake.getProductsContextMutex.RLock()
+	defer fake.getProductsContextMutex.RUnlock()
+	return fake.getProductsContextArgsForCall[i].ctx
+}
+
+func (fake *FakeReleaseRequester) GetProductsContextReturns(result1 *resource.Products, result2 error) {
+	fake.GetProductsContextStub = nil
+	fake.getProductsContextReturns = struct {
+		result1 *resource.Products
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) GetProductContext(ctx context.Context, productName string) (*resource.Product, error) {
+	fake.getProductContextMutex.Lock()
+	fake.getProductContextArgsForCall = append(fake.getProductContextArgsForCall, struct {
+		ctx         context.Context
+		productName string
+	}{ctx, productName})
+	fake.getProductContextMutex.Unlock()
+	if fake.GetProductContextStub != nil {
+		return fake.GetProductContextStub(ctx, productName)
+	} else {
+		return fake.getProductContextReturns.result1, fake.getProductContextReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) GetProductContextCallCount() int {
+	fake.getProductContextMutex.RLock()
+	defer fake.getProductContextMutex.RUnlock()
+	return len(fake.getProductContextArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) GetProductContextArgsForCall(i int) (context.Context, string) {
+	fake.getProductContextMutex.RLock()
+	defer fake.getProductContextMutex.RUnlock()
+	return fake.getProductContextArgsForCall[i].ctx, fake.getProductContextArgsForCall[i].productName
+}
+
+func (fake *FakeReleaseRequester) GetProductContextReturns(result1 *resource.Product, result2 error) {
+	fake.GetProductContextStub = nil
+	fake.getProductContextReturns = struct {
+		result1 *resource.Product
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) GetProductFilesContext(ctx context.Context, release resource.Release) (*resource.ProductFiles, error) {
+	fake.getProductFilesContextMutex.Lock()
+	fake.getProductFilesContextArgsForCall = append(fake.getProductFilesContextArgsForCall, struct {
+		ctx     context.Context
+		release resource.Release
+	}{ctx, release})
+	fake.getProductFilesContextMutex.Unlock()
+	if fake.GetProductFilesContextStub != nil {
+		return fake.GetProductFilesContextStub(ctx, release)
+	} else {
+		return fake.getProductFilesContextReturns.result1, fake.getProductFilesContextReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) GetProductFilesContextCallCount() int {
+	fake.getProductFilesContextMutex.RLock()
+	defer fake.getProductFilesContextMutex.RUnlock()
+	return len(fake.getProductFilesContextArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) GetProductFilesContextArgsForCall(i int) (context.Context, resource.Release) {
+	fake.getProductFilesContextMutex.RLock()
+	defer fake.getProductFilesContextMutex.RUnlock()
+	return fake.getProductFilesContextArgsForCall[i].ctx, fake.getProductFilesContextArgsForCall[i].release
+}
+
+func (fake *FakeReleaseRequester) GetProductFilesContextReturns(result1 *resource.ProductFiles, result2 error) {
+	fake.GetProductFilesContextStub = nil
+	fake.getProductFilesContextReturns = struct {
+		result1 *resource.ProductFiles
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) GetProductDownloadUrlContext(ctx context.Context, productFile *resource.ProductFile) (string, error) {
+	fake.getProductDownloadUrlContextMutex.Lock()
+	fake.getProductDownloadUrlContextArgsForCall = append(fake.getProductDownloadUrlContextArgsForCall, struct {
+		ctx         context.Context
+		productFile *resource.ProductFile
+	}{ctx, productFile})
+	fake.getProductDownloadUrlContextMutex.Unlock()
+	if fake.GetProductDownloadUrlContextStub != nil {
+		return fake.GetProductDownloadUrlContextStub(ctx, productFile)
+	} else {
+		return fake.getProductDownloadUrlContextReturns.result1, fake.getProductDownloadUrlContextReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) GetProductDownloadUrlContextCallCount() int {
+	fake.getProductDownloadUrlContextMutex.RLock()
+	defer fake.getProductDownloadUrlContextMutex.RUnlock()
+	return len(fake.getProductDownloadUrlContextArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) GetProductDownloadUrlContextArgsForCall(i int) (context.Context, *resource.ProductFile) {
+	fake.getProductDownloadUrlContextMutex.RLock()
+	defer fake.getProductDownloadUrlContextMutex.RUnlock()
+	return fake.getProductDownloadUrlContextArgsForCall[i].ctx, fake.getProductDownloadUrlContextArgsForCall[i].productFile
+}
+
+func (fake *FakeReleaseRequester) GetProductDownloadUrlContextReturns(result1 string, result2 error) {
+	fake.GetProductDownloadUrlContextStub = nil
+	fake.getProductDownloadUrlContextReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) GetEulaContext(ctx context.Context, slug string) (*resource.Eula, error) {
+	fake.getEulaContextMutex.Lock()
+	fake.getEulaContextArgsForCall = append(fake.getEulaContextArgsForCall, struct {
+		ctx  context.Context
+		slug string
+	}{ctx, slug})
+	fake.getEulaContextMutex.Unlock()
+	if fake.GetEulaContextStub != nil {
+		return fake.GetEulaContextStub(ctx, slug)
+	} else {
+		return fake.getEulaContextReturns.result1, fake.getEulaContextReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) GetEulaContextCallCount() int {
+	fake.getEulaContextMutex.RLock()
+	defer fake.getEulaContextMutex.RUnlock()
+	return len(fake.getEulaContextArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) GetEulaContextArgsForCall(i int) (context.Context, string) {
+	fake.getEulaContextMutex.RLock()
+	defer fake.getEulaContextMutex.RUnlock()
+	return fake.getEulaContextArgsForCall[i].ctx, fake.getEulaContextArgsForCall[i].slug
+}
+
+func (fake *FakeReleaseRequester) GetEulaContextReturns(result1 *resource.Eula, result2 error) {
+	fake.GetEulaContextStub = nil
+	fake.getEulaContextReturns = struct {
+		result1 *resource.Eula
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) CreateReleaseContext(ctx context.Context, productSlug string, release resource.NewRelease) (*resource.Release, error) {
+	fake.createReleaseContextMutex.Lock()
+	fake.createReleaseContextArgsForCall = append(fake.createReleaseContextArgsForCall, struct {
+		ctx         context.Context
+		productSlug string
+		release     resource.NewRelease
+	}{ctx, productSlug, release})
+	fake.createReleaseContextMutex.Unlock()
+	if fake.CreateReleaseContextStub != nil {
+		return fake.CreateReleaseContextStub(ctx, productSlug, release)
+	} else {
+		return fake.createReleaseContextReturns.result1, fake.createReleaseContextReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) CreateReleaseContextCallCount() int {
+	fake.createReleaseContextMutex.RLock()
+	defer fake.createReleaseContextMutex.RUnlock()
+	return len(fake.createReleaseContextArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) CreateReleaseContextArgsForCall(i int) (context.Context, string, resource.NewRelease) {
+	fake.createReleaseContextMutex.RLock()
+	defer fake.createReleaseContextMutex.RUnlock()
+	return fake.createReleaseContextArgsForCall[i].ctx, fake.createReleaseContextArgsForCall[i].productSlug, fake.createReleaseContextArgsForCall[i].release
+}
+
+func (fake *FakeReleaseRequester) CreateReleaseContextReturns(result1 *resource.Release, result2 error) {
+	fake.CreateReleaseContextStub = nil
+	fake.createReleaseContextReturns = struct {
+		result1 *resource.Release
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) UpdateReleaseAvailabilityContext(ctx context.Context, productSlug string, releaseId int, availability string, userGroupIds []int) error {
+	fake.updateReleaseAvailabilityContextMutex.Lock()
+	fake.updateReleaseAvailabilityContextArgsForCall = append(fake.updateReleaseAvailabilityContextArgsForCall, struct {
+		ctx          context.Context
+		productSlug  string
+		releaseId    int
+		availability string
+		userGroupIds []int
+	}{ctx, productSlug, releaseId, availability, userGroupIds})
+	fake.updateReleaseAvailabilityContextMutex.Unlock()
+	if fake.UpdateReleaseAvailabilityContextStub != nil {
+		return fake.UpdateReleaseAvailabilityContextStub(ctx, productSlug, releaseId, availability, userGroupIds)
+	} else {
+		return fake.updateReleaseAvailabilityContextReturns.result1
+	}
+}
+
+func (fake *FakeReleaseRequester) UpdateReleaseAvailabilityContextCallCount() int {
+	fake.updateReleaseAvailabilityContextMutex.RLock()
+	defer fake.updateReleaseAvailabilityContextMutex.RUnlock()
+	return len(fake.updateReleaseAvailabilityContextArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) UpdateReleaseAvailabilityContextArgsForCall(i int) (context.Context, string, int, string, []int) {
+	fake.updateReleaseAvailabilityContextMutex.RLock()
+	defer fake.updateReleaseAvailabilityContextMutex.RUnlock()
+	return fake.updateReleaseAvailabilityContextArgsForCall[i].ctx, fake.updateReleaseAvailabilityContextArgsForCall[i].productSlug, fake.updateReleaseAvailabilityContextArgsForCall[i].releaseId, fake.updateReleaseAvailabilityContextArgsForCall[i].availability, fake.updateReleaseAvailabilityContextArgsForCall[i].userGroupIds
+}
+
+func (fake *FakeReleaseRequester) UpdateReleaseAvailabilityContextReturns(result1 error) {
+	fake.UpdateReleaseAvailabilityContextStub = nil
+	fake.updateReleaseAvailabilityContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeReleaseRequester) DeleteReleaseContext(ctx context.Context, productSlug string, releaseId int) error {
+	fake.deleteReleaseContextMutex.Lock()
+	fake.deleteReleaseContextArgsForCall = append(fake.deleteReleaseContextArgsForCall, struct {
+		ctx         context.Context
+		productSlug string
+		releaseId   int
+	}{ctx, productSlug, releaseId})
+	fake.deleteReleaseContextMutex.Unlock()
+	if fake.DeleteReleaseContextStub != nil {
+		return fake.DeleteReleaseContextStub(ctx, productSlug, releaseId)
+	} else {
+		return fake.deleteReleaseContextReturns.result1
+	}
+}
+
+func (fake *FakeReleaseRequester) DeleteReleaseContextCallCount() int {
+	fake.deleteReleaseContextMutex.RLock()
+	defer fake.deleteReleaseContextMutex.RUnlock()
+	return len(fake.deleteReleaseContextArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) DeleteReleaseContextArgsForCall(i int) (context.Context, string, int) {
+	fake.deleteReleaseContextMutex.RLock()
+	defer fake.deleteReleaseContextMutex.RUnlock()
+	return fake.deleteReleaseContextArgsForCall[i].ctx, fake.deleteReleaseContextArgsForCall[i].productSlug, fake.deleteReleaseContextArgsForCall[i].releaseId
+}
+
+func (fake *FakeReleaseRequester) DeleteReleaseContextReturns(result1 error) {
+	fake.DeleteReleaseContextStub = nil
+	fake.deleteReleaseContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeReleaseRequester) GetUploadCredentialsContext(ctx context.Context, productSlug string) (*resource.UploadCredentials, error) {
+	fake.getUploadCredentialsContextMutex.Lock()
+	fake.getUploadCredentialsContextArgsForCall = append(fake.getUploadCredentialsContextArgsForCall, struct {
+		ctx         context.Context
+		productSlug string
+	}{ctx, productSlug})
+	fake.getUploadCredentialsContextMutex.Unlock()
+	if fake.GetUploadCredentialsContextStub != nil {
+		return fake.GetUploadCredentialsContextStub(ctx, productSlug)
+	} else {
+		return fake.getUploadCredentialsContextReturns.result1, fake.getUploadCredentialsContextReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) GetUploadCredentialsContextCallCount() int {
+	fake.getUploadCredentialsContextMutex.RLock()
+	defer fake.getUploadCredentialsContextMutex.RUnlock()
+	return len(fake.getUploadCredentialsContextArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) GetUploadCredentialsContextArgsForCall(i int) (context.Context, string) {
+	fake.getUploadCredentialsContextMutex.RLock()
+	defer fake.getUploadCredentialsContextMutex.RUnlock()
+	return fake.getUploadCredentialsContextArgsForCall[i].ctx, fake.getUploadCredentialsContextArgsForCall[i].productSlug
+}
+
+func (fake *FakeReleaseRequester) GetUploadCredentialsContextReturns(result1 *resource.UploadCredentials, result2 error) {
+	fake.GetUploadCredentialsContextStub = nil
+	fake.getUploadCredentialsContextReturns = struct {
+		result1 *resource.UploadCredentials
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) UploadFileContext(ctx context.Context, productSlug string, objectKey string, content io.Reader) error {
+	fake.uploadFileContextMutex.Lock()
+	fake.uploadFileContextArgsForCall = append(fake.uploadFileContextArgsForCall, struct {
+		ctx         context.Context
+		productSlug string
+		objectKey   string
+		content     io.Reader
+	}{ctx, productSlug, objectKey, content})
+	fake.uploadFileContextMutex.Unlock()
+	if fake.UploadFileContextStub != nil {
+		return fake.UploadFileContextStub(ctx, productSlug, objectKey, content)
+	} else {
+		return fake.uploadFileContextReturns.result1
+	}
+}
+
+func (fake *FakeReleaseRequester) UploadFileContextCallCount() int {
+	fake.uploadFileContextMutex.RLock()
+	defer fake.uploadFileContextMutex.RUnlock()
+	return len(fake.uploadFileContextArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) UploadFileContextArgsForCall(i int) (context.Context, string, string, io.Reader) {
+	fake.uploadFileContextMutex.RLock()
+	defer fake.uploadFileContextMutex.RUnlock()
+	return fake.uploadFileContextArgsForCall[i].ctx, fake.uploadFileContextArgsForCall[i].productSlug, fake.uploadFileContextArgsForCall[i].objectKey, fake.uploadFileContextArgsForCall[i].content
+}
+
+func (fake *FakeReleaseRequester) UploadFileContextReturns(result1 error) {
+	fake.UploadFileContextStub = nil
+	fake.uploadFileContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeReleaseRequester) CreateProductFileContext(ctx context.Context, productSlug string, productFile resource.NewProductFile) (*resource.ProductFile, error) {
+	fake.createProductFileContextMutex.Lock()
+	fake.createProductFileContextArgsForCall = append(fake.createProductFileContextArgsForCall, struct {
+		ctx         context.Context
+		productSlug string
+		productFile resource.NewProductFile
+	}{ctx, productSlug, productFile})
+	fake.createProductFileContextMutex.Unlock()
+	if fake.CreateProductFileContextStub != nil {
+		return fake.CreateProductFileContextStub(ctx, productSlug, productFile)
+	} else {
+		return fake.createProductFileContextReturns.result1, fake.createProductFileContextReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) CreateProductFileContextCallCount() int {
+	fake.createProductFileContextMutex.RLock()
+	defer fake.createProductFileContextMutex.RUnlock()
+	return len(fake.createProductFileContextArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) CreateProductFileContextArgsForCall(i int) (context.Context, string, resource.NewProductFile) {
+	fake.createProductFileContextMutex.RLock()
+	defer fake.createProductFileContextMutex.RUnlock()
+	return fake.createProductFileContextArgsForCall[i].ctx, fake.createProductFileContextArgsForCall[i].productSlug, fake.createProductFileContextArgsForCall[i].productFile
+}
+
+func (fake *FakeReleaseRequester) CreateProductFileContextReturns(result1 *resource.ProductFile, result2 error) {
+	fake.CreateProductFileContextStub = nil
+	fake.createProductFileContextReturns = struct {
+		result1 *resource.ProductFile
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) AddProductFileContext(ctx context.Context, productSlug string, releaseId int, productFileId int) error {
+	fake.addProductFileContextMutex.Lock()
+	fake.addProductFileContextArgsForCall = append(fake.addProductFileContextArgsForCall, struct {
+		ctx           context.Context
+		productSlug   string
+		releaseId     int
+		productFileId int
+	}{ctx, productSlug, releaseId, productFileId})
+	fake.addProductFileContextMutex.Unlock()
+	if fake.AddProductFileContextStub != nil {
+		return fake.AddProductFileContextStub(ctx, productSlug, releaseId, productFileId)
+	} else {
+		return fake.addProductFileContextReturns.result1
+	}
+}
+
+func (fake *FakeReleaseRequester) AddProductFileContextCallCount() int {
+	fake.addProductFileContextMutex.RLock()
+	defer fake.addProductFileContextMutex.RUnlock()
+	return len(fake.addProductFileContextArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) AddProductFileContextArgsForCall(i int) (context.Context, string, int, int) {
+	fake.addProductFileContextMutex.RLock()
+	defer fake.addProductFileContextMutex.RUnlock()
+	return fake.addProductFileContextArgsForCall[i].ctx, fake.addProductFileContextArgsForCall[i].productSlug, fake.addProductFileContextArgsForCall[i].releaseId, fake.addProductFileContextArgsForCall[i].productFileId
+}
+
+func (fake *FakeReleaseRequester) AddProductFileContextReturns(result1 error) {
+	fake.AddProductFileContextStub = nil
+	fake.addProductFileContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeReleaseRequester) GetReleaseDependenciesContext(ctx context.Context, productSlug string, releaseId int) (*resource.ReleaseDependencies, error) {
+	fake.getReleaseDependenciesContextMutex.Lock()
+	fake.getReleaseDependenciesContextArgsForCall = append(fake.getReleaseDependenciesContextArgsForCall, struct {
+		ctx         context.Context
+		productSlug string
+		releaseId   int
+	}{ctx, productSlug, releaseId})
+	fake.getReleaseDependenciesContextMutex.Unlock()
+	if fake.GetReleaseDependenciesContextStub != nil {
+		return fake.GetReleaseDependenciesContextStub(ctx, productSlug, releaseId)
+	} else {
+		return fake.getReleaseDependenciesContextReturns.result1, fake.getReleaseDependenciesContextReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) GetReleaseDependenciesContextCallCount() int {
+	fake.getReleaseDependenciesContextMutex.RLock()
+	defer fake.getReleaseDependenciesContextMutex.RUnlock()
+	return len(fake.getReleaseDependenciesContextArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) GetReleaseDependenciesContextArgsForCall(i int) (context.Context, string, int) {
+	fake.getReleaseDependenciesContextMutex.RLock()
+	defer fake.getReleaseDependenciesContextMutex.RUnlock()
+	return fake.getReleaseDependenciesContextArgsForCall[i].ctx, fake.getReleaseDependenciesContextArgsForCall[i].productSlug, fake.getReleaseDependenciesContextArgsForCall[i].releaseId
+}
+
+func (fake *FakeReleaseRequester) GetReleaseDependenciesContextReturns(result1 *resource.ReleaseDependencies, result2 error) {
+	fake.GetReleaseDependenciesContextStub = nil
+	fake.getReleaseDependenciesContextReturns = struct {
+		result1 *resource.ReleaseDependencies
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) GetUpgradePathsContext(ctx context.Context, productSlug string, releaseId int) (*resource.UpgradePaths, error) {
+	fake.getUpgradePathsContextMutex.Lock()
+	fake.getUpgradePathsContextArgsForCall = append(fake.getUpgradePathsContextArgsForCall, struct {
+		ctx         context.Context
+		productSlug string
+		releaseId   int
+	}{ctx, productSlug, releaseId})
+	fake.getUpgradePathsContextMutex.Unlock()
+	if fake.GetUpgradePathsContextStub != nil {
+		return fake.GetUpgradePathsContextStub(ctx, productSlug, releaseId)
+	} else {
+		return fake.getUpgradePathsContextReturns.result1, fake.getUpgradePathsContextReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) GetUpgradePathsContextCallCount() int {
+	fake.getUpgradePathsContextMutex.RLock()
+	defer fake.getUpgradePathsContextMutex.RUnlock()
+	return len(fake.getUpgradePathsContextArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) GetUpgradePathsContextArgsForCall(i int) (context.Context, string, int) {
+	fake.getUpgradePathsContextMutex.RLock()
+	defer fake.getUpgradePathsContextMutex.RUnlock()
+	return fake.getUpgradePathsContextArgsForCall[i].ctx, fake.getUpgradePathsContextArgsForCall[i].productSlug, fake.getUpgradePathsContextArgsForCall[i].releaseId
+}
+
+func (fake *FakeReleaseRequester) GetUpgradePathsContextReturns(result1 *resource.UpgradePaths, result2 error) {
+	fake.GetUpgradePathsContextStub = nil
+	fake.getUpgradePathsContextReturns = struct {
+		result1 *resource.UpgradePaths
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeReleaseRequester) GetProductFileContext(ctx context.Context, productSlug string, releaseId int, productFileId int) (*resource.ProductFile, error) {
+	fake.getProductFileContextMutex.Lock()
+	fake.getProductFileContextArgsForCall = append(fake.getProductFileContextArgsForCall, struct {
+		ctx           context.Context
+		productSlug   string
+		releaseId     int
+		productFileId int
+	}{ctx, productSlug, releaseId, productFileId})
+	fake.getProductFileContextMutex.Unlock()
+	if fake.GetProductFileContextStub != nil {
+		return fake.GetProductFileContextStub(ctx, productSlug, releaseId, productFileId)
+	} else {
+		return fake.getProductFileContextReturns.result1, fake.getProductFileContextReturns.result2
+	}
+}
+
+func (fake *FakeReleaseRequester) GetProductFileContextCallCount() int {
+	fake.getProductFileContextMutex.RLock()
+	defer fake.getProductFileContextMutex.RUnlock()
+	return len(fake.getProductFileContextArgsForCall)
+}
+
+func (fake *FakeReleaseRequester) GetProductFileContextArgsForCall(i int) (context.Context, string, int, int) {
+	fake.getProductFileContextMutex.RLock()
+	defer fake.getProductFileContextMutex.RUnlock()
+	return fake.getProductFileContextArgsForCall[i].ctx, fake.getProductFileContextArgsForCall[i].productSlug, fake.getProductFileContextArgsForCall[i].releaseId, fake.getProductFileContextArgsForCall[i].productFileId
+}
+
+func (fake *FakeReleaseRequester) GetProductFileContextReturns(result1 *resource.ProductFile, result2 error) {
+	fake.GetProductFileContextStub = nil
+	fake.getProductFileContextReturns = struct {
+		result1 *resource.ProductFile
+		result2 error
+	}{result1, result2}
+}
+
 var _ resource.ReleaseRequester = new(FakeReleaseRequester)
@@ -2,27 +2,144 @@ package resource
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"time"
 )
 
 type pivnetClient struct {
-	token string
+	httpClient     *http.Client
+	auth           Authenticator
+	retryPolicy    RetryPolicy
+	requestTimeout time.Duration
 }
 
-func (p *pivnetClient) Do(req *http.Request) (resp *http.Response, err error) {
-	p.setPivnetHeaders(req)
+// requesterConfig gathers the ClientOptions passed to NewRequester.
+type requesterConfig struct {
+	retryPolicy    RetryPolicy
+	requestTimeout time.Duration
+	httpClient     *http.Client
+	authenticator  Authenticator
+	eulaPolicy     EulaPolicy
+	eulaAuditLog   io.Writer
+	s3Endpoint     string
+	uploadPartSize int64
+}
+
+type ClientOption func(*requesterConfig)
+
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *requesterConfig) {
+		c.retryPolicy = policy
+	}
+}
 
-	return http.DefaultClient.Do(req)
+// WithRequestTimeout limits how long a single api request, or a single
+// request of an upload to S3, may take, including reading the response body.
+// Zero means no limit.
+func WithRequestTimeout(timeout time.Duration) ClientOption {
+	return func(c *requesterConfig) {
+		c.requestTimeout = timeout
+	}
+}
+
+// WithHttpClient sends requests, including uploads to S3, with client. Its
+// Timeout is replaced by the one set with WithRequestTimeout.
+func WithHttpClient(client *http.Client) ClientOption {
+	return func(c *requesterConfig) {
+		c.httpClient = client
+	}
+}
+
+func (p *pivnetClient) Do(req *http.Request) (resp *http.Response, err error) {
+	client := p.timedClient()
+	return p.withRetries(req, client.Do)
 }
 
 func (p *pivnetClient) DoWithoutRedirect(req *http.Request) (resp *http.Response, err error) {
-	p.setPivnetHeaders(req)
-	return http.DefaultTransport.RoundTrip(req)
+	client := p.timedClient()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return p.withRetries(req, client.Do)
+}
+
+// timedClient returns a copy of the http client limited to the request
+// timeout.
+func (p *pivnetClient) timedClient() *http.Client {
+	client := *p.httpClient
+	client.Timeout = p.requestTimeout
+	return &client
+}
+
+func (p *pivnetClient) withRetries(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	attempts := p.retryPolicy.Attempts()
+	reauthenticated := false
+	for attempt := 1; ; attempt++ {
+		authorization, err := p.setPivnetHeaders(req)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := send(req)
+
+		// An expired access token is renewed once, without counting as an
+		// attempt. The server didn't act on the request, so it is safe to
+		// send it again whatever its method.
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && !reauthenticated && p.auth.Expire(authorization) {
+			reauthenticated = true
+			attempt--
+			err = rewind(req, resp)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		retry := err != nil || RetryableStatus(resp.StatusCode)
+		if !retry || attempt >= attempts || !isIdempotent(req) || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		err = rewind(req, resp)
+		if err != nil {
+			return nil, err
+		}
+
+		timer := time.NewTimer(p.retryPolicy.Backoff(attempt, resp))
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// rewind discards resp and resets the body of req so it can be sent again.
+func rewind(req *http.Request, resp *http.Response) error {
+	if resp != nil {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		req.Body = body
+	}
+	return nil
 }
 
-func (p *pivnetClient) setPivnetHeaders(req *http.Request) {
-	req.Header.Set("Authorization", "Token "+p.token)
+func (p *pivnetClient) setPivnetHeaders(req *http.Request) (string, error) {
+	authorization, err := p.auth.Authorization(withExchangeClient(req.Context(), p.timedClient()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", authorization)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", fmt.Sprintf(`gopivnet/%s`, Version))
+	return authorization, nil
 }
@@ -0,0 +1,140 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// NewRelease describes a release to create. EulaSlug and ReleaseType are
+// required by Pivotal Network.
+type NewRelease struct {
+	Version         string `json:"version"`
+	ReleaseType     string `json:"release_type"`
+	EulaSlug        string `json:"eula_slug"`
+	Description     string `json:"description,omitempty"`
+	ReleaseNotesUrl string `json:"release_notes_url,omitempty"`
+	ReleaseDate     string `json:"release_date,omitempty"`
+	Availability    string `json:"availability,omitempty"`
+}
+
+// NewProductFile describes a product file to create for an object already
+// uploaded with UploadFile.
+type NewProductFile struct {
+	Name         string `json:"name"`
+	AwsObjectKey string `json:"aws_object_key"`
+	FileVersion  string `json:"file_version"`
+	FileType     string `json:"file_type"`
+	Sha256       string `json:"sha256,omitempty"`
+	Md5          string `json:"md5,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+func (p *PivnetRequester) CreateRelease(productSlug string, release NewRelease) (*Release, error) {
+	return p.CreateReleaseContext(context.Background(), productSlug, release)
+}
+
+func (p *PivnetRequester) CreateReleaseContext(ctx context.Context, productSlug string, release NewRelease) (*Release, error) {
+	requestUrl := fmt.Sprintf("%s/api/v2/products/%s/releases", p.pivnetUrl, productSlug)
+	req := newJSONRequest(ctx, "POST", requestUrl, map[string]interface{}{"release": release})
+
+	created := struct {
+		Release Release `json:"release"`
+	}{}
+	err := p.getJSON(req, &created)
+	if err != nil {
+		return nil, err
+	}
+	return &created.Release, nil
+}
+
+func (p *PivnetRequester) UpdateReleaseAvailability(productSlug string, releaseId int, availability string, userGroupIds []int) error {
+	return p.UpdateReleaseAvailabilityContext(context.Background(), productSlug, releaseId, availability, userGroupIds)
+}
+
+// UpdateReleaseAvailabilityContext sets who can see the release and, for
+// "Selected User Groups Only", adds the given user groups to it.
+func (p *PivnetRequester) UpdateReleaseAvailabilityContext(ctx context.Context, productSlug string, releaseId int, availability string, userGroupIds []int) error {
+	releaseUrl := fmt.Sprintf("%s/api/v2/products/%s/releases/%d", p.pivnetUrl, productSlug, releaseId)
+
+	req := newJSONRequest(ctx, "PATCH", releaseUrl, map[string]interface{}{
+		"release": map[string]string{"availability": availability},
+	})
+	err := p.doWithoutBody(req)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range userGroupIds {
+		req = newJSONRequest(ctx, "PATCH", releaseUrl+"/add_user_group", map[string]interface{}{
+			"user_group": map[string]int{"id": id},
+		})
+		err = p.doWithoutBody(req)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *PivnetRequester) DeleteRelease(productSlug string, releaseId int) error {
+	return p.DeleteReleaseContext(context.Background(), productSlug, releaseId)
+}
+
+func (p *PivnetRequester) DeleteReleaseContext(ctx context.Context, productSlug string, releaseId int) error {
+	requestUrl := fmt.Sprintf("%s/api/v2/products/%s/releases/%d", p.pivnetUrl, productSlug, releaseId)
+	req, _ := http.NewRequestWithContext(ctx, "DELETE", requestUrl, nil)
+	return p.doWithoutBody(req)
+}
+
+func (p *PivnetRequester) CreateProductFile(productSlug string, productFile NewProductFile) (*ProductFile, error) {
+	return p.CreateProductFileContext(context.Background(), productSlug, productFile)
+}
+
+func (p *PivnetRequester) CreateProductFileContext(ctx context.Context, productSlug string, productFile NewProductFile) (*ProductFile, error) {
+	requestUrl := fmt.Sprintf("%s/api/v2/products/%s/product_files", p.pivnetUrl, productSlug)
+	req := newJSONRequest(ctx, "POST", requestUrl, map[string]interface{}{"product_file": productFile})
+
+	created := struct {
+		ProductFile ProductFile `json:"product_file"`
+	}{}
+	err := p.getJSON(req, &created)
+	if err != nil {
+		return nil, err
+	}
+	return &created.ProductFile, nil
+}
+
+func (p *PivnetRequester) AddProductFile(productSlug string, releaseId, productFileId int) error {
+	return p.AddProductFileContext(context.Background(), productSlug, releaseId, productFileId)
+}
+
+// AddProductFileContext attaches an existing product file to a release.
+func (p *PivnetRequester) AddProductFileContext(ctx context.Context, productSlug string, releaseId, productFileId int) error {
+	requestUrl := fmt.Sprintf("%s/api/v2/products/%s/releases/%d/add_product_file", p.pivnetUrl, productSlug, releaseId)
+	req := newJSONRequest(ctx, "PATCH", requestUrl, map[string]interface{}{
+		"product_file": map[string]int{"id": productFileId},
+	})
+	return p.doWithoutBody(req)
+}
+
+func newJSONRequest(ctx context.Context, method, url string, body interface{}) *http.Request {
+	payload, _ := json.Marshal(body)
+	req, _ := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(payload))
+	return req
+}
+
+func (p *PivnetRequester) doWithoutBody(req *http.Request) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(req, resp)
+	}
+	resp.Body.Close()
+	return nil
+}
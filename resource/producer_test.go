@@ -0,0 +1,272 @@
+package resource_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cfmobile/gopivnet/resource"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Producer", func() {
+	var (
+		req    resource.ReleaseRequester
+		server *ghttp.Server
+	)
+
+	verifyHeaders := ghttp.CombineHandlers(
+		ghttp.VerifyHeaderKV("Authorization", "Token token"),
+		ghttp.VerifyHeaderKV("Content-Type", "application/json"),
+		ghttp.VerifyHeaderKV("User-Agent", fmt.Sprintf("gopivnet/%s", resource.Version)),
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		req = resource.NewRequester(server.URL(), "token")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("CreateRelease", func() {
+		It("posts the release and returns the created release", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v2/products/my-prod/releases"),
+					verifyHeaders,
+					ghttp.VerifyJSON(`{"release":{"version":"1.2.0","release_type":"Minor Release","eula_slug":"pivotal-eula","availability":"Admins Only"}}`),
+					ghttp.RespondWith(http.StatusCreated, `{"release":{"id":321,"version":"1.2.0","release_type":"Minor Release","availability":"Admins Only"}}`),
+				),
+			)
+
+			release, err := req.CreateRelease("my-prod", resource.NewRelease{
+				Version:      "1.2.0",
+				ReleaseType:  "Minor Release",
+				EulaSlug:     "pivotal-eula",
+				Availability: "Admins Only",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.Id).To(Equal(321))
+			Expect(release.Version).To(Equal("1.2.0"))
+		})
+
+		It("returns an error if the server rejects the release", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusForbidden, ""))
+
+			_, err := req.CreateRelease("my-prod", resource.NewRelease{Version: "1.2.0"})
+			var apiErr *resource.APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.StatusCode).To(Equal(http.StatusForbidden))
+		})
+	})
+
+	Context("UpdateReleaseAvailability", func() {
+		It("sets the availability and adds each user group", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PATCH", "/api/v2/products/my-prod/releases/321"),
+					verifyHeaders,
+					ghttp.VerifyJSON(`{"release":{"availability":"Selected User Groups Only"}}`),
+					ghttp.RespondWith(http.StatusOK, `{}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PATCH", "/api/v2/products/my-prod/releases/321/add_user_group"),
+					ghttp.VerifyJSON(`{"user_group":{"id":4}}`),
+					ghttp.RespondWith(http.StatusNoContent, ""),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PATCH", "/api/v2/products/my-prod/releases/321/add_user_group"),
+					ghttp.VerifyJSON(`{"user_group":{"id":9}}`),
+					ghttp.RespondWith(http.StatusNoContent, ""),
+				),
+			)
+
+			err := req.UpdateReleaseAvailability("my-prod", 321, "Selected User Groups Only", []int{4, 9})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("stops at the first failure", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, ""))
+
+			err := req.UpdateReleaseAvailability("my-prod", 321, "Selected User Groups Only", []int{4})
+			Expect(errors.Is(err, resource.ErrNotFound)).To(BeTrue())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+
+	Context("DeleteRelease", func() {
+		It("deletes the release", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/api/v2/products/my-prod/releases/321"),
+					verifyHeaders,
+					ghttp.RespondWith(http.StatusNoContent, ""),
+				),
+			)
+
+			Expect(req.DeleteRelease("my-prod", 321)).To(Succeed())
+		})
+	})
+
+	Context("product files", func() {
+		It("creates a product file", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v2/products/my-prod/product_files"),
+					verifyHeaders,
+					ghttp.VerifyJSON(`{"product_file":{"name":"My Tile","aws_object_key":"product-files/my-prod/my-prod.pivotal","file_version":"1.2.0","file_type":"Software","sha256":"abc"}}`),
+					ghttp.RespondWith(http.StatusCreated, `{"product_file":{"id":500,"aws_object_key":"product-files/my-prod/my-prod.pivotal","sha256":"abc"}}`),
+				),
+			)
+
+			productFile, err := req.CreateProductFile("my-prod", resource.NewProductFile{
+				Name:         "My Tile",
+				AwsObjectKey: "product-files/my-prod/my-prod.pivotal",
+				FileVersion:  "1.2.0",
+				FileType:     "Software",
+				Sha256:       "abc",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(productFile.Id).To(Equal(500))
+			Expect(productFile.Sha256).To(Equal("abc"))
+		})
+
+		It("adds a product file to a release", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PATCH", "/api/v2/products/my-prod/releases/321/add_product_file"),
+					verifyHeaders,
+					ghttp.VerifyJSON(`{"product_file":{"id":500}}`),
+					ghttp.RespondWith(http.StatusNoContent, ""),
+				),
+			)
+
+			Expect(req.AddProductFile("my-prod", 321, 500)).To(Succeed())
+		})
+	})
+
+	Context("UploadFile", func() {
+		var s3 *ghttp.Server
+
+		credentials := `{"access_key_id":"key","secret_access_key":"secret","session_token":"session","bucket":"pivnet-bucket","region":"us-west-2"}`
+		objectPath := "/pivnet-bucket/product-files/my-prod/my tile.pivotal"
+
+		verifySigned := ghttp.CombineHandlers(
+			ghttp.VerifyHeaderKV("X-Amz-Security-Token", "session"),
+			ghttp.VerifyHeaderKV("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD"),
+			func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Header.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=key/"))
+			},
+		)
+
+		BeforeEach(func() {
+			s3 = ghttp.NewServer()
+			req = resource.NewRequester(server.URL(), "token",
+				resource.WithS3Endpoint(s3.URL()),
+				resource.WithUploadPartSize(4),
+			)
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v2/federation_token"),
+					verifyHeaders,
+					ghttp.VerifyJSON(`{"product_id":"my-prod"}`),
+					ghttp.RespondWith(http.StatusOK, credentials),
+				),
+			)
+		})
+
+		AfterEach(func() {
+			s3.Close()
+		})
+
+		It("uploads the content in parts and completes the upload", func() {
+			s3.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", objectPath, "uploads="),
+					verifySigned,
+					ghttp.RespondWith(http.StatusOK, `<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", objectPath, "partNumber=1&uploadId=upload-1"),
+					verifySigned,
+					ghttp.VerifyBody([]byte("abcd")),
+					ghttp.RespondWith(http.StatusOK, "", http.Header{"ETag": []string{`"etag-1"`}}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", objectPath, "partNumber=2&uploadId=upload-1"),
+					ghttp.VerifyBody([]byte("ef")),
+					ghttp.RespondWith(http.StatusOK, "", http.Header{"ETag": []string{`"etag-2"`}}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", objectPath, "uploadId=upload-1"),
+					verifySigned,
+					ghttp.VerifyBody([]byte(`<CompleteMultipartUpload><Part><PartNumber>1</PartNumber><ETag>&#34;etag-1&#34;</ETag></Part><Part><PartNumber>2</PartNumber><ETag>&#34;etag-2&#34;</ETag></Part></CompleteMultipartUpload>`)),
+					ghttp.RespondWith(http.StatusOK, `<CompleteMultipartUploadResult></CompleteMultipartUploadResult>`),
+				),
+			)
+
+			err := req.UploadFile("my-prod", "product-files/my-prod/my tile.pivotal", strings.NewReader("abcdef"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s3.ReceivedRequests()).To(HaveLen(4))
+		})
+
+		It("uploads with the http client and request timeout of the requester", func() {
+			transport := &countingTransport{}
+			req = resource.NewRequester(server.URL(), "token",
+				resource.WithS3Endpoint(s3.URL()),
+				resource.WithHttpClient(&http.Client{Transport: transport}),
+				resource.WithRequestTimeout(20*time.Millisecond),
+			)
+			s3.AppendHandlers(
+				func(w http.ResponseWriter, r *http.Request) {
+					time.Sleep(200 * time.Millisecond)
+				},
+				ghttp.RespondWith(http.StatusNoContent, ""),
+			)
+
+			err := req.UploadFile("my-prod", "product-files/my-prod/my tile.pivotal", strings.NewReader("abcdef"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Timeout"))
+			Expect(transport.requests).To(Equal(2))
+		})
+
+		It("aborts the upload when a part fails", func() {
+			s3.AppendHandlers(
+				ghttp.RespondWith(http.StatusOK, `<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`),
+				ghttp.RespondWith(http.StatusForbidden, `<Error><Code>AccessDenied</Code></Error>`),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", objectPath, "uploadId=upload-1"),
+					verifySigned,
+					ghttp.RespondWith(http.StatusNoContent, ""),
+				),
+			)
+
+			err := req.UploadFile("my-prod", "product-files/my-prod/my tile.pivotal", strings.NewReader("abcdef"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("AccessDenied"))
+			Expect(s3.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("aborts the upload when S3 fails to complete it", func() {
+			s3.AppendHandlers(
+				ghttp.RespondWith(http.StatusOK, `<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`),
+				ghttp.RespondWith(http.StatusOK, "", http.Header{"ETag": []string{`"etag-1"`}}),
+				ghttp.RespondWith(http.StatusOK, `<Error><Code>InternalError</Code></Error>`),
+				ghttp.VerifyRequest("DELETE", objectPath, "uploadId=upload-1"),
+			)
+
+			err := req.UploadFile("my-prod", "product-files/my-prod/my tile.pivotal", strings.NewReader("ab"))
+			Expect(err).To(HaveOccurred())
+			Expect(s3.ReceivedRequests()).To(HaveLen(4))
+		})
+	})
+})
@@ -0,0 +1,37 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type Products struct {
+	Products []ProductSummary `json:"products"`
+}
+
+// ProductSummary is a product as listed by /api/v2/products, without its
+// releases.
+type ProductSummary struct {
+	Id    int    `json:"id"`
+	Slug  string `json:"slug"`
+	Name  string `json:"name"`
+	Links Links  `json:"_links"`
+}
+
+func (p *PivnetRequester) GetProducts() (*Products, error) {
+	return p.GetProductsContext(context.Background())
+}
+
+// GetProductsContext returns every product the token gives access to.
+func (p *PivnetRequester) GetProductsContext(ctx context.Context) (*Products, error) {
+	requestUrl := fmt.Sprintf("%s/api/v2/products", p.pivnetUrl)
+	req, _ := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
+
+	products := Products{}
+	err := p.getJSON(req, &products)
+	if err != nil {
+		return nil, err
+	}
+	return &products, nil
+}
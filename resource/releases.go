@@ -1,57 +1,152 @@
 package resource
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
 )
 
 var RequireEula = 451
 
+// ReleaseRequester talks to the Pivotal Network api. The *Context variants
+// abort the request when ctx is done; the others use context.Background().
 type ReleaseRequester interface {
+	GetProducts() (*Products, error)
 	GetProduct(productName string) (*Product, error)
 	GetProductFiles(release Release) (*ProductFiles, error)
 	GetProductDownloadUrl(productFile *ProductFile) (string, error)
+	GetEula(slug string) (*Eula, error)
+	CreateRelease(productSlug string, release NewRelease) (*Release, error)
+	UpdateReleaseAvailability(productSlug string, releaseId int, availability string, userGroupIds []int) error
+	DeleteRelease(productSlug string, releaseId int) error
+	GetUploadCredentials(productSlug string) (*UploadCredentials, error)
+	UploadFile(productSlug, objectKey string, content io.Reader) error
+	CreateProductFile(productSlug string, productFile NewProductFile) (*ProductFile, error)
+	AddProductFile(productSlug string, releaseId, productFileId int) error
+	GetReleaseDependencies(productSlug string, releaseId int) (*ReleaseDependencies, error)
+	GetUpgradePaths(productSlug string, releaseId int) (*UpgradePaths, error)
+	GetProductFile(productSlug string, releaseId, productFileId int) (*ProductFile, error)
+	GetProductsContext(ctx context.Context) (*Products, error)
+	GetProductContext(ctx context.Context, productName string) (*Product, error)
+	GetProductFilesContext(ctx context.Context, release Release) (*ProductFiles, error)
+	GetProductDownloadUrlContext(ctx context.Context, productFile *ProductFile) (string, error)
+	GetEulaContext(ctx context.Context, slug string) (*Eula, error)
+	CreateReleaseContext(ctx context.Context, productSlug string, release NewRelease) (*Release, error)
+	UpdateReleaseAvailabilityContext(ctx context.Context, productSlug string, releaseId int, availability string, userGroupIds []int) error
+	DeleteReleaseContext(ctx context.Context, productSlug string, releaseId int) error
+	GetUploadCredentialsContext(ctx context.Context, productSlug string) (*UploadCredentials, error)
+	UploadFileContext(ctx context.Context, productSlug, objectKey string, content io.Reader) error
+	CreateProductFileContext(ctx context.Context, productSlug string, productFile NewProductFile) (*ProductFile, error)
+	AddProductFileContext(ctx context.Context, productSlug string, releaseId, productFileId int) error
+	GetReleaseDependenciesContext(ctx context.Context, productSlug string, releaseId int) (*ReleaseDependencies, error)
+	GetUpgradePathsContext(ctx context.Context, productSlug string, releaseId int) (*UpgradePaths, error)
+	GetProductFileContext(ctx context.Context, productSlug string, releaseId, productFileId int) (*ProductFile, error)
 }
 
+// HttpClient sends requests to Pivotal Network. Requests carry their context,
+// which the client honours while sending and between retries.
 type HttpClient interface {
 	Do(req *http.Request) (resp *http.Response, err error)
 	DoWithoutRedirect(req *http.Request) (resp *http.Response, err error)
 }
 
-func NewRequester(url string, token string) ReleaseRequester {
+func NewRequester(url string, token string, options ...ClientOption) ReleaseRequester {
+	c := requesterConfig{
+		retryPolicy: DefaultRetryPolicy,
+		eulaPolicy:  AutoAcceptEula,
+	}
+	for _, option := range options {
+		option(&c)
+	}
+	if c.httpClient == nil {
+		c.httpClient = http.DefaultClient
+	}
+	if c.authenticator == nil {
+		c.authenticator = NewAuthenticator(url, token)
+	}
+
+	client := &pivnetClient{
+		httpClient:     c.httpClient,
+		auth:           c.authenticator,
+		retryPolicy:    c.retryPolicy,
+		requestTimeout: c.requestTimeout,
+	}
 	return &PivnetRequester{
-		pivnetUrl: url,
-		client:    &pivnetClient{token: token},
+		pivnetUrl:      url,
+		client:         client,
+		eulaPolicy:     c.eulaPolicy,
+		eulaAuditLog:   c.eulaAuditLog,
+		s3Endpoint:     c.s3Endpoint,
+		uploadPartSize: c.uploadPartSize,
+		uploadClient:   client.timedClient(),
 	}
 }
 
 type PivnetRequester struct {
-	pivnetUrl string
-	client    HttpClient
+	pivnetUrl      string
+	client         HttpClient
+	eulaPolicy     EulaPolicy
+	eulaAuditLog   io.Writer
+	s3Endpoint     string
+	uploadPartSize int64
+	// uploadClient sends the requests to S3, which take no Pivotal Network
+	// authorization, with the transport and request timeout of client.
+	uploadClient *http.Client
 }
 
-func (p *PivnetRequester) getProductRequest(productName string) *http.Request {
+func (p *PivnetRequester) getProductRequest(ctx context.Context, productName string) *http.Request {
 	requestUrl := fmt.Sprintf("%s/api/v2/products/%s/releases", p.pivnetUrl, productName)
 
-	req, _ := http.NewRequest("GET", requestUrl, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
 	return req
 }
 
 func (p *PivnetRequester) GetProduct(productName string) (*Product, error) {
-	req := p.getProductRequest(productName)
+	return p.GetProductContext(context.Background(), productName)
+}
+
+// GetProductContext returns every release of the product, following the
+// "next" links of paginated responses.
+func (p *PivnetRequester) GetProductContext(ctx context.Context, productName string) (*Product, error) {
+	req := p.getProductRequest(ctx, productName)
+
+	product, err := p.getProductPage(req)
+	if err != nil {
+		return nil, err
+	}
+
+	visited := map[string]bool{req.URL.String(): true}
+	page := product
+	for {
+		next, ok := page.Links["next"]
+		if !ok || next.Url == "" || visited[next.Url] {
+			break
+		}
+		visited[next.Url] = true
+
+		req, _ = http.NewRequestWithContext(ctx, "GET", next.Url, nil)
+		page, err = p.getProductPage(req)
+		if err != nil {
+			return nil, err
+		}
+		product.Releases = append(product.Releases, page.Releases...)
+	}
 
+	return product, nil
+}
+
+func (p *PivnetRequester) getProductPage(req *http.Request) (*Product, error) {
 	resp, err := p.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		logRequestAndResponse(req, resp)
-		return nil, errors.New("bad status code from server")
+		return nil, newAPIError(req, resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -65,20 +160,23 @@ func (p *PivnetRequester) GetProduct(productName string) (*Product, error) {
 }
 
 func (p *PivnetRequester) GetProductFiles(release Release) (*ProductFiles, error) {
+	return p.GetProductFilesContext(context.Background(), release)
+}
+
+func (p *PivnetRequester) GetProductFilesContext(ctx context.Context, release Release) (*ProductFiles, error) {
 	productFilesLink, ok := release.Links["product_files"]
 	if !ok {
 		return nil, errors.New("Unable to get product files")
 	}
 
-	req, _ := http.NewRequest("GET", productFilesLink.Url, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", productFilesLink.Url, nil)
 	resp, err := p.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		logRequestAndResponse(req, resp)
-		return nil, errors.New("bad status code from server")
+		return nil, newAPIError(req, resp)
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -92,24 +190,55 @@ func (p *PivnetRequester) GetProductFiles(release Release) (*ProductFiles, error
 	return &productFiles, nil
 }
 
+func (p *PivnetRequester) GetProductFile(productSlug string, releaseId, productFileId int) (*ProductFile, error) {
+	return p.GetProductFileContext(context.Background(), productSlug, releaseId, productFileId)
+}
+
+// GetProductFileContext returns a single file of a release, including its
+// checksums.
+func (p *PivnetRequester) GetProductFileContext(ctx context.Context, productSlug string, releaseId, productFileId int) (*ProductFile, error) {
+	requestUrl := fmt.Sprintf("%s/api/v2/products/%s/releases/%d/product_files/%d", p.pivnetUrl, productSlug, releaseId, productFileId)
+	req, _ := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
+
+	response := struct {
+		ProductFile ProductFile `json:"product_file"`
+	}{}
+	err := p.getJSON(req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.ProductFile, nil
+}
+
 func (p *PivnetRequester) GetProductDownloadUrl(productFile *ProductFile) (string, error) {
+	return p.GetProductDownloadUrlContext(context.Background(), productFile)
+}
+
+func (p *PivnetRequester) GetProductDownloadUrlContext(ctx context.Context, productFile *ProductFile) (string, error) {
 	downloadLink, ok := productFile.Links["download"]
 	if !ok {
 		return "", errors.New("Unable to get product files")
 	}
 
-	req, _ := http.NewRequest("POST", downloadLink.Url, nil)
+	req, _ := http.NewRequestWithContext(ctx, "POST", downloadLink.Url, nil)
+	req = markIdempotent(req)
 	resp, err := p.client.DoWithoutRedirect(req)
 	if err != nil {
 		return "", err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode == 451 {
 		body, _ := ioutil.ReadAll(resp.Body)
 		eula := EulaMessage{}
 		json.Unmarshal(body, &eula)
 
-		err = p.acceptEula(eula.Links["eula_agreement"].Url)
+		agreement, ok := eula.Links["eula_agreement"]
+		if !ok {
+			return "", fmt.Errorf("%w: no eula agreement link in the response", ErrEulaRequired)
+		}
+
+		err = p.approveEula(ctx, agreement.Url)
 		if err != nil {
 			return "", err
 		}
@@ -117,32 +246,29 @@ func (p *PivnetRequester) GetProductDownloadUrl(productFile *ProductFile) (strin
 		if err != nil {
 			return "", err
 		}
+		defer resp.Body.Close()
 	}
 
 	if resp.StatusCode != http.StatusFound {
-		logRequestAndResponse(req, resp)
-		return "", errors.New("bad status code from server")
+		return "", newAPIError(req, resp)
 	}
 
 	downloadUrl := resp.Header.Get("Location")
 	return downloadUrl, nil
 }
 
-func (p *PivnetRequester) acceptEula(url string) error {
-	req, _ := http.NewRequest("POST", url, nil)
+func (p *PivnetRequester) acceptEula(ctx context.Context, url string) error {
+	req, _ := http.NewRequestWithContext(ctx, "POST", url, nil)
+	req = markIdempotent(req)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		logRequestAndResponse(req, resp)
-		return errors.New("Unable to accept eula")
+		return fmt.Errorf("Unable to accept eula: %w", newAPIError(req, resp))
 	}
 	return nil
 }
-
-func logRequestAndResponse(req *http.Request, resp *http.Response) {
-	log.Printf("\nRequest: %v\nResponse: %v\n", req, resp)
-}
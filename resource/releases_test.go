@@ -1,8 +1,14 @@
 package resource_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/cfmobile/gopivnet/resource"
 
@@ -75,6 +81,41 @@ var _ = Describe("Releases", func() {
 		}
 	})
 
+	Context("GetProducts", func() {
+		It("returns the products listed by the server", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/products"),
+					verifyHeaders,
+					ghttp.RespondWith(http.StatusOK, `{"products":[{"id":60,"slug":"elastic-runtime","name":"Elastic Runtime"}]}`),
+				),
+			)
+
+			products, err := req.GetProducts()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(products.Products).To(Equal([]resource.ProductSummary{
+				resource.ProductSummary{Id: 60, Slug: "elastic-runtime", Name: "Elastic Runtime"},
+			}))
+		})
+
+		It("sends requests with the http client", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"products":[]}`))
+			transport := &countingTransport{}
+			req = resource.NewRequester(server.URL(), "token", resource.WithHttpClient(&http.Client{Transport: transport}))
+
+			_, err := req.GetProducts()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(transport.requests).To(Equal(1))
+		})
+
+		It("returns an error if the request fails", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusUnauthorized, ""))
+
+			_, err := req.GetProducts()
+			Expect(errors.Is(err, resource.ErrUnauthorized)).To(BeTrue())
+		})
+	})
+
 	Context("GetProduct", func() {
 		It("return an error if the token is not valid", func() {
 			server.AppendHandlers(
@@ -130,6 +171,232 @@ var _ = Describe("Releases", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(prod).To(Equal(&serverProd))
 		})
+
+		It("follows the next links and returns the releases of every page", func() {
+			firstPage := resource.Product{
+				Releases: []resource.Release{resource.Release{Id: 12, Version: "1.2"}},
+				Links: resource.Links{
+					"next": resource.Link{Url: server.URL() + "/api/v2/products/my-prod/releases?page=2"},
+				},
+			}
+			secondPage := resource.Product{
+				Releases: []resource.Release{resource.Release{Id: 11, Version: "1.1"}},
+				Links: resource.Links{
+					"next": resource.Link{Url: server.URL() + "/api/v2/products/my-prod/releases?page=3"},
+				},
+			}
+			lastPage := resource.Product{
+				Releases: []resource.Release{resource.Release{Id: 10, Version: "1.0"}},
+			}
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/products/my-prod/releases"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, firstPage),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/products/my-prod/releases", "page=2"),
+					verifyHeaders,
+					ghttp.RespondWithJSONEncoded(http.StatusOK, secondPage),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/products/my-prod/releases", "page=3"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, lastPage),
+				),
+			)
+
+			prod, err := req.GetProduct("my-prod")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+
+			Expect(prod.Releases).To(HaveLen(3))
+			Expect(prod.Releases[0].Id).To(Equal(12))
+			Expect(prod.Releases[1].Id).To(Equal(11))
+			Expect(prod.Releases[2].Id).To(Equal(10))
+		})
+
+		It("stops if a page links back to one already fetched", func() {
+			page := resource.Product{
+				Releases: []resource.Release{resource.Release{Id: 12, Version: "1.2"}},
+				Links: resource.Links{
+					"next": resource.Link{Url: server.URL() + "/api/v2/products/my-prod/releases"},
+				},
+			}
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/products/my-prod/releases"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, page),
+				),
+			)
+
+			prod, err := req.GetProduct("my-prod")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+			Expect(prod.Releases).To(HaveLen(1))
+		})
+
+		It("returns an error if fetching a later page fails", func() {
+			firstPage := resource.Product{
+				Releases: []resource.Release{resource.Release{Id: 12, Version: "1.2"}},
+				Links: resource.Links{
+					"next": resource.Link{Url: server.URL() + "/api/v2/products/my-prod/releases?page=2"},
+				},
+			}
+
+			server.AppendHandlers(
+				ghttp.RespondWithJSONEncoded(http.StatusOK, firstPage),
+				ghttp.RespondWith(http.StatusNotFound, ""),
+			)
+
+			_, err := req.GetProduct("my-prod")
+			Expect(errors.Is(err, resource.ErrNotFound)).To(BeTrue())
+		})
+	})
+
+	Context("errors", func() {
+		BeforeEach(func() {
+			req = resource.NewRequester(server.URL(), "token", resource.WithRetryPolicy(resource.NoRetries))
+		})
+
+		It("returns an APIError with the parsed error body", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusUnprocessableEntity, `{"status": 422, "message": "invalid request", "errors": ["bad slug"]}`),
+			)
+
+			_, err := req.GetProduct("my-prod")
+
+			var apiErr *resource.APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.StatusCode).To(Equal(http.StatusUnprocessableEntity))
+			Expect(apiErr.Method).To(Equal("GET"))
+			Expect(apiErr.URL).To(Equal(server.URL() + "/api/v2/products/my-prod/releases"))
+			Expect(apiErr.Message).To(Equal("invalid request"))
+			Expect(apiErr.Errors).To(Equal([]string{"bad slug"}))
+			Expect(errors.Unwrap(err)).To(BeNil())
+		})
+
+		It("doesn't leak the token", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusUnauthorized, ""),
+			)
+
+			_, err := req.GetProduct("my-prod")
+			Expect(err.Error()).ToNot(ContainSubstring("token"))
+		})
+
+		It("classifies the status codes", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusUnauthorized, ""),
+				ghttp.RespondWith(http.StatusNotFound, ""),
+				ghttp.RespondWith(http.StatusTooManyRequests, ""),
+				ghttp.RespondWith(resource.RequireEula, ""),
+			)
+
+			_, err := req.GetProduct("my-prod")
+			Expect(errors.Is(err, resource.ErrUnauthorized)).To(BeTrue())
+
+			_, err = req.GetProductFiles(*testRelease)
+			Expect(errors.Is(err, resource.ErrNotFound)).To(BeTrue())
+
+			_, err = req.GetProduct("my-prod")
+			Expect(errors.Is(err, resource.ErrRateLimited)).To(BeTrue())
+
+			_, err = req.GetProductDownloadUrl(&pivotalProductFile)
+			Expect(errors.Is(err, resource.ErrEulaRequired)).To(BeTrue())
+		})
+	})
+
+	Context("context", func() {
+		It("doesn't send the request if the context is already done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := req.GetProductContext(ctx, "my-prod")
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Expect(server.ReceivedRequests()).To(HaveLen(0))
+		})
+
+		It("stops retrying when the context is done", func() {
+			req = resource.NewRequester(server.URL(), "token", resource.WithRetryPolicy(resource.RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: time.Hour,
+			}))
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusBadGateway, ""),
+			)
+
+			_, err := req.GetProductContext(ctx, "my-prod")
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+
+	Context("retries", func() {
+		BeforeEach(func() {
+			req = resource.NewRequester(server.URL(), "token", resource.WithRetryPolicy(resource.RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: time.Millisecond,
+			}))
+		})
+
+		It("retries requests that fail with a retryable status", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusBadGateway, ""),
+				ghttp.RespondWith(http.StatusTooManyRequests, "", http.Header{"Retry-After": []string{"0"}}),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/products/my-prod/releases"),
+					verifyHeaders,
+					ghttp.RespondWithJSONEncoded(http.StatusOK, resource.Product{}),
+				),
+			)
+
+			_, err := req.GetProduct("my-prod")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("gives up after the maximum number of attempts", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusServiceUnavailable, ""),
+				ghttp.RespondWith(http.StatusServiceUnavailable, ""),
+				ghttp.RespondWith(http.StatusServiceUnavailable, ""),
+			)
+
+			_, err := req.GetProduct("my-prod")
+			Expect(err).To(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("doesn't retry client errors", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusUnauthorized, ""),
+			)
+
+			_, err := req.GetProduct("my-prod")
+			Expect(err).To(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("retries the idempotent download request", func() {
+			returnHeader := http.Header{}
+			returnHeader.Add("Location", "testUrl")
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusBadGateway, ""),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v2/products/my-prod/releases/123/product_files/200/download"),
+					verifyHeaders,
+					ghttp.RespondWith(http.StatusFound, "", returnHeader),
+				),
+			)
+
+			url, err := req.GetProductDownloadUrl(&pivotalProductFile)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+			Expect(url).To(Equal("testUrl"))
+		})
 	})
 
 	Context("GetProductFiles", func() {
@@ -192,6 +459,33 @@ var _ = Describe("Releases", func() {
 		})
 	})
 
+	Context("GetProductFile", func() {
+		It("returns the product file", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/products/my-prod/releases/123/product_files/200"),
+					verifyHeaders,
+					ghttp.RespondWith(http.StatusOK, `{"product_file":{"id":200,"aws_object_key":"product-files/my-prod/product.pivotal","file_version":"1.0","sha256":"abc"}}`),
+				),
+			)
+
+			productFile, err := req.GetProductFile("my-prod", 123, 200)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(productFile.Id).To(Equal(200))
+			Expect(productFile.AwsObjectKey).To(Equal("product-files/my-prod/product.pivotal"))
+			Expect(productFile.FileVersion).To(Equal("1.0"))
+			Expect(productFile.Sha256).To(Equal("abc"))
+		})
+
+		It("returns an error if the file doesn't exist", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, ""))
+
+			_, err := req.GetProductFile("my-prod", 123, 200)
+			Expect(errors.Is(err, resource.ErrNotFound)).To(BeTrue())
+		})
+	})
+
 	Context("GetProductDownloadUrl", func() {
 		It("returns an error if the token is not valid", func() {
 			server.AppendHandlers(
@@ -265,5 +559,159 @@ var _ = Describe("Releases", func() {
 			Expect(server.ReceivedRequests()).To(HaveLen(3))
 			Expect(url).To(Equal("testUrl"))
 		})
+
+		Context("eula policies", func() {
+			var auditLog *bytes.Buffer
+			var releaseWithEula resource.Release
+			var eula resource.Eula
+
+			BeforeEach(func() {
+				auditLog = &bytes.Buffer{}
+				releaseWithEula = resource.Release{
+					Id:      123,
+					Version: "1.1",
+					Eula:    resource.Eula{Id: 7, Slug: "pivotal-eula", Name: "Pivotal EULA"},
+				}
+				eula = resource.Eula{
+					Id:      7,
+					Slug:    "pivotal-eula",
+					Name:    "Pivotal EULA",
+					Content: "<p>You agree &amp; accept.</p>",
+				}
+			})
+
+			lookupHandlers := func() []http.HandlerFunc {
+				return []http.HandlerFunc{
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/api/v2/products/my-prod/releases/123/product_files/200/download"),
+						ghttp.RespondWithJSONEncoded(resource.RequireEula, eulaMessage),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v2/products/my-prod/releases/123"),
+						verifyHeaders,
+						ghttp.RespondWithJSONEncoded(http.StatusOK, releaseWithEula),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v2/eulas/pivotal-eula"),
+						verifyHeaders,
+						ghttp.RespondWithJSONEncoded(http.StatusOK, eula),
+					),
+				}
+			}
+
+			acceptHandlers := func() []http.HandlerFunc {
+				returnHeader := http.Header{}
+				returnHeader.Add("Location", "testUrl")
+				return []http.HandlerFunc{
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/api/v2/products/my-prod/releases/123/eula_acceptance"),
+						ghttp.RespondWith(http.StatusOK, ""),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/api/v2/products/my-prod/releases/123/product_files/200/download"),
+						ghttp.RespondWith(http.StatusFound, "", returnHeader),
+					),
+				}
+			}
+
+			It("doesn't accept the eula with RejectEula", func() {
+				req = resource.NewRequester(server.URL(), "token", resource.WithEulaPolicy(resource.RejectEula))
+				server.AppendHandlers(lookupHandlers()...)
+
+				_, err := req.GetProductDownloadUrl(&pivotalProductFile)
+				Expect(errors.Is(err, resource.ErrEulaRequired)).To(BeTrue())
+				Expect(err.Error()).To(ContainSubstring("pivotal-eula"))
+				Expect(server.ReceivedRequests()).To(HaveLen(3))
+			})
+
+			It("accepts only the listed eulas and records them in the audit log", func() {
+				req = resource.NewRequester(server.URL(), "token",
+					resource.WithEulaPolicy(resource.AcceptEulas("other-eula", "pivotal-eula")),
+					resource.WithEulaAuditLog(auditLog),
+				)
+				server.AppendHandlers(append(lookupHandlers(), acceptHandlers()...)...)
+
+				url, err := req.GetProductDownloadUrl(&pivotalProductFile)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(url).To(Equal("testUrl"))
+
+				acceptance := resource.EulaAcceptance{}
+				Expect(json.Unmarshal(auditLog.Bytes(), &acceptance)).To(Succeed())
+				Expect(acceptance.Product).To(Equal("my-prod"))
+				Expect(acceptance.ReleaseId).To(Equal(123))
+				Expect(acceptance.ReleaseVersion).To(Equal("1.1"))
+				Expect(acceptance.EulaSlug).To(Equal("pivotal-eula"))
+				Expect(acceptance.User).ToNot(BeEmpty())
+				Expect(acceptance.Time).ToNot(BeZero())
+			})
+
+			It("doesn't accept a eula that isn't listed", func() {
+				req = resource.NewRequester(server.URL(), "token",
+					resource.WithEulaPolicy(resource.AcceptEulas("other-eula")),
+					resource.WithEulaAuditLog(auditLog),
+				)
+				server.AppendHandlers(lookupHandlers()...)
+
+				_, err := req.GetProductDownloadUrl(&pivotalProductFile)
+				Expect(errors.Is(err, resource.ErrEulaRequired)).To(BeTrue())
+				Expect(auditLog.Len()).To(Equal(0))
+			})
+
+			It("shows the eula and accepts it when the user agrees", func() {
+				out := &bytes.Buffer{}
+				req = resource.NewRequester(server.URL(), "token",
+					resource.WithEulaPolicy(resource.PromptForEula(strings.NewReader("yes\n"), out)),
+				)
+				server.AppendHandlers(append(lookupHandlers(), acceptHandlers()...)...)
+
+				_, err := req.GetProductDownloadUrl(&pivotalProductFile)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out.String()).To(ContainSubstring("Pivotal EULA"))
+				Expect(out.String()).To(ContainSubstring("You agree & accept."))
+			})
+
+			It("doesn't accept the eula when the user doesn't answer", func() {
+				req = resource.NewRequester(server.URL(), "token",
+					resource.WithEulaPolicy(resource.PromptForEula(strings.NewReader(""), &bytes.Buffer{})),
+				)
+				server.AppendHandlers(lookupHandlers()...)
+
+				_, err := req.GetProductDownloadUrl(&pivotalProductFile)
+				Expect(errors.Is(err, resource.ErrEulaRequired)).To(BeTrue())
+			})
+		})
+	})
+
+	Context("GetEula", func() {
+		It("returns the eula with its content", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v2/eulas/pivotal-eula"),
+					verifyHeaders,
+					ghttp.RespondWith(http.StatusOK, `{"id":7,"slug":"pivotal-eula","name":"Pivotal EULA","content":"<p>terms</p>"}`),
+				),
+			)
+
+			eula, err := req.GetEula("pivotal-eula")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(eula.Name).To(Equal("Pivotal EULA"))
+			Expect(eula.Text()).To(Equal("terms"))
+		})
+
+		It("returns an error if the eula doesn't exist", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, ""))
+
+			_, err := req.GetEula("missing")
+			Expect(errors.Is(err, resource.ErrNotFound)).To(BeTrue())
+		})
 	})
 })
+
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
@@ -4,6 +4,7 @@ import "strings"
 
 type Product struct {
 	Releases []Release `json:"releases"`
+	Links    Links     `json:"_links"`
 }
 
 type Release struct {
@@ -19,9 +20,11 @@ type Release struct {
 }
 
 type Eula struct {
-	Id   int    `json:"id"`
-	Slug string `json:"slug"`
-	Name string `json:"name"`
+	Id      int    `json:"id"`
+	Slug    string `json:"slug"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	Links   Links  `json:"_links"`
 }
 
 type Links map[string]Link
@@ -36,8 +39,12 @@ type ProductFiles struct {
 
 type ProductFile struct {
 	Id           int    `json:"id"`
+	DisplayName  string `json:"name"`
 	AwsObjectKey string `json:"aws_object_key"`
 	FileVersion  string `json:"file_version"`
+	Size         int64  `json:"size"`
+	Sha256       string `json:"sha256"`
+	Md5          string `json:"md5"`
 	Links        Links  `json:"_links"`
 }
 
@@ -0,0 +1,54 @@
+package resource_test
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/cfmobile/gopivnet/resource"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RetryPolicy", func() {
+	policy := RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+	}
+
+	It("doubles the backoff after every attempt up to the maximum", func() {
+		Expect(policy.Backoff(1, nil)).To(Equal(time.Second))
+		Expect(policy.Backoff(2, nil)).To(Equal(2 * time.Second))
+		Expect(policy.Backoff(3, nil)).To(Equal(4 * time.Second))
+		Expect(policy.Backoff(4, nil)).To(Equal(5 * time.Second))
+		Expect(policy.Backoff(60, nil)).To(Equal(5 * time.Second))
+	})
+
+	It("randomizes the backoff by the jitter", func() {
+		jittered := policy
+		jittered.Jitter = 0.5
+
+		for i := 0; i < 20; i++ {
+			backoff := jittered.Backoff(1, nil)
+			Expect(backoff).To(BeNumerically(">=", 500*time.Millisecond))
+			Expect(backoff).To(BeNumerically("<=", 1500*time.Millisecond))
+		}
+	})
+
+	It("honors Retry-After on 429 and 503 responses", func() {
+		resp := &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": []string{"42"}},
+		}
+		Expect(policy.Backoff(1, resp)).To(Equal(42 * time.Second))
+
+		resp.StatusCode = http.StatusBadGateway
+		Expect(policy.Backoff(1, resp)).To(Equal(time.Second))
+	})
+
+	It("always makes at least one attempt", func() {
+		Expect(RetryPolicy{}.Attempts()).To(Equal(1))
+		Expect(policy.Attempts()).To(Equal(5))
+	})
+})
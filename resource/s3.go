@@ -0,0 +1,318 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultUploadPartSize is the size of the parts of a multipart upload to S3.
+// S3 requires every part but the last to be at least 5 MiB.
+const DefaultUploadPartSize = 64 << 20
+
+// UploadCredentials are the temporary AWS credentials Pivotal Network hands
+// out to upload product files to its bucket.
+type UploadCredentials struct {
+	AccessKeyId     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	SessionToken    string `json:"session_token"`
+	Bucket          string `json:"bucket"`
+	Region          string `json:"region"`
+}
+
+// WithS3Endpoint sends uploads to endpoint instead of the regional S3
+// endpoint of the bucket, e.g. for an S3 compatible test server. Objects are
+// addressed as <endpoint>/<bucket>/<key>.
+func WithS3Endpoint(endpoint string) ClientOption {
+	return func(c *requesterConfig) {
+		c.s3Endpoint = endpoint
+	}
+}
+
+func WithUploadPartSize(size int64) ClientOption {
+	return func(c *requesterConfig) {
+		c.uploadPartSize = size
+	}
+}
+
+func (p *PivnetRequester) GetUploadCredentials(productSlug string) (*UploadCredentials, error) {
+	return p.GetUploadCredentialsContext(context.Background(), productSlug)
+}
+
+func (p *PivnetRequester) GetUploadCredentialsContext(ctx context.Context, productSlug string) (*UploadCredentials, error) {
+	requestUrl := fmt.Sprintf("%s/api/v2/federation_token", p.pivnetUrl)
+	req := newJSONRequest(ctx, "POST", requestUrl, map[string]string{"product_id": productSlug})
+
+	credentials := UploadCredentials{}
+	err := p.getJSON(req, &credentials)
+	if err != nil {
+		return nil, err
+	}
+	return &credentials, nil
+}
+
+func (p *PivnetRequester) UploadFile(productSlug, objectKey string, content io.Reader) error {
+	return p.UploadFileContext(context.Background(), productSlug, objectKey, content)
+}
+
+// UploadFileContext uploads content to objectKey in the Pivotal Network
+// bucket as an S3 multipart upload, using credentials from
+// GetUploadCredentials. A failed upload is aborted so S3 drops its parts.
+func (p *PivnetRequester) UploadFileContext(ctx context.Context, productSlug, objectKey string, content io.Reader) error {
+	credentials, err := p.GetUploadCredentialsContext(ctx, productSlug)
+	if err != nil {
+		return err
+	}
+
+	upload := &s3Upload{
+		ctx:         ctx,
+		client:      p.uploadClient,
+		credentials: credentials,
+		objectUrl:   p.objectUrl(credentials, objectKey),
+	}
+
+	err = upload.start()
+	if err != nil {
+		return err
+	}
+
+	err = upload.sendParts(content, p.partSize())
+	if err == nil {
+		err = upload.complete()
+	}
+	if err != nil {
+		upload.abort()
+		return fmt.Errorf("Unable to upload %s: %w", objectKey, err)
+	}
+	return nil
+}
+
+func (p *PivnetRequester) objectUrl(credentials *UploadCredentials, objectKey string) string {
+	endpoint := p.s3Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", credentials.Region)
+	}
+
+	segments := strings.Split(strings.TrimPrefix(objectKey, "/"), "/")
+	for index := range segments {
+		segments[index] = url.PathEscape(segments[index])
+	}
+	return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(endpoint, "/"), credentials.Bucket, strings.Join(segments, "/"))
+}
+
+func (p *PivnetRequester) partSize() int64 {
+	if p.uploadPartSize <= 0 {
+		return DefaultUploadPartSize
+	}
+	return p.uploadPartSize
+}
+
+type s3Upload struct {
+	ctx         context.Context
+	client      *http.Client
+	credentials *UploadCredentials
+	objectUrl   string
+	uploadId    string
+	parts       []completedPart
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (u *s3Upload) start() error {
+	resp, err := u.send("POST", "uploads=", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	result := struct {
+		UploadId string `xml:"UploadId"`
+	}{}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	err = xml.Unmarshal(body, &result)
+	if err != nil {
+		return err
+	}
+	u.uploadId = result.UploadId
+	return nil
+}
+
+func (u *s3Upload) sendParts(content io.Reader, partSize int64) error {
+	buffer := make([]byte, partSize)
+	for number := 1; ; number++ {
+		n, err := io.ReadFull(content, buffer)
+		if err == io.EOF && number > 1 {
+			return nil
+		}
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+
+		query := fmt.Sprintf("partNumber=%d&uploadId=%s", number, url.QueryEscape(u.uploadId))
+		resp, sendErr := u.send("PUT", query, buffer[:n])
+		if sendErr != nil {
+			return sendErr
+		}
+		resp.Body.Close()
+		u.parts = append(u.parts, completedPart{PartNumber: number, ETag: resp.Header.Get("ETag")})
+
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+func (u *s3Upload) complete() error {
+	body, _ := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: u.parts})
+
+	resp, err := u.send("POST", "uploadId="+url.QueryEscape(u.uploadId), body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// S3 may report a failure in the body of a 200 response.
+	result, _ := ioutil.ReadAll(resp.Body)
+	if bytes.Contains(result, []byte("<Error>")) {
+		return fmt.Errorf("S3 failed to complete the upload: %s", result)
+	}
+	return nil
+}
+
+func (u *s3Upload) abort() {
+	if u.uploadId == "" {
+		return
+	}
+	resp, err := u.send("DELETE", "uploadId="+url.QueryEscape(u.uploadId), nil)
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
+func (u *s3Upload) send(method, query string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(u.ctx, method, u.objectUrl+"?"+query, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	signS3Request(req, u.credentials, time.Now().UTC())
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		message, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s %s: %d %s: %s", method, req.URL.Path, resp.StatusCode, http.StatusText(resp.StatusCode), message)
+	}
+	return resp, nil
+}
+
+// signS3Request adds an AWS signature version 4 to req. The payload isn't
+// signed, which S3 allows over TLS.
+func signS3Request(req *http.Request, credentials *UploadCredentials, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", now.Format("20060102"), credentials.Region)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSha256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSha256([]byte("AWS4"+credentials.SecretAccessKey), now.Format("20060102"))
+	key = hmacSha256(key, credentials.Region)
+	key = hmacSha256(key, "s3")
+	key = hmacSha256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.AccessKeyId, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, key := range keys {
+		for _, value := range values[key] {
+			pairs = append(pairs, awsEscape(key)+"="+awsEscape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape percent-encodes everything but the unreserved characters, as
+// signature version 4 requires.
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSha256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
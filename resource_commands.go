@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/cfmobile/gopivnet/api"
+	"github.com/cfmobile/gopivnet/concourse"
+	"github.com/cfmobile/gopivnet/config"
+)
+
+// The check, in and out commands speak the Concourse resource protocol: the
+// request is read as JSON from stdin and the response written as JSON to
+// stdout. Anything else printed while working goes to stderr.
+
+func checkCommand(args []string) {
+	request := concourse.CheckRequest{}
+	readRequest(&request)
+
+	runResourceStep(request.Source, func(pivnetApi api.Api) (interface{}, error) {
+		ctx, cancel := cancelOnSignal(0)
+		defer cancel()
+		return concourse.Check(ctx, pivnetApi, request)
+	})
+}
+
+func inCommand(args []string) {
+	if len(args) < 1 {
+		usageError("Usage: gopivnet in <destination directory>")
+	}
+
+	request := concourse.InRequest{}
+	readRequest(&request)
+
+	runResourceStep(request.Source, func(pivnetApi api.Api) (interface{}, error) {
+		ctx, cancel := cancelOnSignal(0)
+		defer cancel()
+		return concourse.In(ctx, pivnetApi, args[0], request)
+	})
+}
+
+func outCommand(args []string) {
+	if len(args) < 1 {
+		usageError("Usage: gopivnet out <sources directory>")
+	}
+
+	request := concourse.OutRequest{}
+	readRequest(&request)
+
+	runResourceStep(request.Source, func(pivnetApi api.Api) (interface{}, error) {
+		ctx, cancel := cancelOnSignal(0)
+		defer cancel()
+		return concourse.Out(ctx, pivnetApi, args[0], request)
+	})
+}
+
+func readRequest(request interface{}) {
+	err := json.NewDecoder(os.Stdin).Decode(request)
+	if err != nil {
+		usageError("Invalid request on stdin: " + err.Error())
+	}
+}
+
+func runResourceStep(source concourse.Source, step func(api.Api) (interface{}, error)) {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() {
+		os.Stdout = stdout
+	}()
+
+	profile := config.Profile{}.WithEnv(os.LookupEnv)
+	if source.Endpoint != "" {
+		profile.Url = source.Endpoint
+	}
+	pivnetApi := api.New(pivnetToken(source.ApiToken, profile), api.WithEndpoint(profile.Url))
+
+	response, err := step(pivnetApi)
+	if err != nil {
+		fatal(err)
+	}
+
+	err = json.NewEncoder(stdout).Encode(response)
+	if err != nil {
+		fatal(err)
+	}
+}
@@ -0,0 +1,233 @@
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Constraint is a set of version requirements such as "~> 1.8",
+// ">=1.7.3 <1.9", "1.8.*" or "1.8". Requirements separated by spaces or
+// commas must all hold; alternatives are separated by "||".
+//
+// A version with fewer than three segments or with a wildcard matches every
+// version it is a prefix of, so "1.8" selects the 1.8 patch line.
+// Pre-release versions only match when the constraint mentions a pre-release.
+type Constraint struct {
+	original     string
+	alternatives [][]clause
+}
+
+type clause struct {
+	op      string
+	version Version
+	upper   Version
+}
+
+var errEmptyConstraint = errors.New("Empty version constraint")
+
+var operators = []string{"~>", ">=", "<=", "!=", "==", ">", "<", "=", "~", "^"}
+
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{original: s}
+	if strings.TrimSpace(s) == "" {
+		return c, errEmptyConstraint
+	}
+
+	for _, alternative := range strings.Split(s, "||") {
+		clauses, err := parseAlternative(alternative)
+		if err != nil {
+			return Constraint{}, fmt.Errorf("Invalid version constraint \"%s\": %s", s, err)
+		}
+		c.alternatives = append(c.alternatives, clauses)
+	}
+	return c, nil
+}
+
+func parseAlternative(s string) ([]clause, error) {
+	tokens := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	if len(tokens) == 0 {
+		return nil, errEmptyConstraint
+	}
+
+	clauses := []clause{}
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if isOperator(token) && i+1 < len(tokens) {
+			i++
+			token += tokens[i]
+		}
+
+		op := ""
+		for _, candidate := range operators {
+			if strings.HasPrefix(token, candidate) {
+				op = candidate
+				break
+			}
+		}
+
+		expanded, err := expand(op, strings.TrimPrefix(token, op))
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, expanded...)
+	}
+	return clauses, nil
+}
+
+func isOperator(token string) bool {
+	for _, op := range operators {
+		if token == op {
+			return true
+		}
+	}
+	return false
+}
+
+// expand turns a single requirement into plain comparisons.
+func expand(op, text string) ([]clause, error) {
+	prefix, wildcard, err := parsePattern(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if wildcard && len(prefix.Segments) == 0 {
+		if op == "" || op == "=" || op == "==" || op == ">=" || op == "<=" {
+			return []clause{}, nil
+		}
+		return nil, fmt.Errorf("\"%s%s\" matches no version", op, text)
+	}
+
+	k := len(prefix.Segments)
+	isRange := wildcard || (k < 3 && prefix.Prerelease == "")
+	upper := prefix.bump(k - 1)
+
+	switch op {
+	case "", "=", "==":
+		if isRange {
+			return []clause{{op: ">=", version: prefix}, {op: "<", version: upper}}, nil
+		}
+		return []clause{{op: "=", version: prefix}}, nil
+	case "!=":
+		if isRange {
+			return []clause{{op: "!in", version: prefix, upper: upper}}, nil
+		}
+		return []clause{{op: "!=", version: prefix}}, nil
+	case ">":
+		if wildcard {
+			return []clause{{op: ">=", version: upper}}, nil
+		}
+	case "<=":
+		if wildcard {
+			return []clause{{op: "<", version: upper}}, nil
+		}
+	case ">=", "<":
+	default:
+		if wildcard {
+			return nil, fmt.Errorf("wildcards can't be used with \"%s\"", op)
+		}
+	}
+
+	switch op {
+	case "~>":
+		if k == 1 {
+			return []clause{{op: ">=", version: prefix}}, nil
+		}
+		return []clause{{op: ">=", version: prefix}, {op: "<", version: prefix.bump(k - 2)}}, nil
+	case "~":
+		if k == 1 {
+			return []clause{{op: ">=", version: prefix}, {op: "<", version: prefix.bump(0)}}, nil
+		}
+		return []clause{{op: ">=", version: prefix}, {op: "<", version: prefix.bump(1)}}, nil
+	case "^":
+		i := 0
+		for i < k-1 && prefix.Segments[i] == 0 {
+			i++
+		}
+		return []clause{{op: ">=", version: prefix}, {op: "<", version: prefix.bump(i)}}, nil
+	}
+	return []clause{{op: op, version: prefix}}, nil
+}
+
+// parsePattern parses a version that may end in wildcard segments such as
+// "1.8.*" or "1.x".
+func parsePattern(text string) (Version, bool, error) {
+	if strings.ContainsAny(text, "-+") {
+		v, err := Parse(text)
+		return v, false, err
+	}
+
+	segments := strings.Split(text, ".")
+	for i, segment := range segments {
+		if segment == "*" || segment == "x" || segment == "X" {
+			for _, rest := range segments[i+1:] {
+				if rest != "*" && rest != "x" && rest != "X" {
+					return Version{}, false, fmt.Errorf("Invalid version \"%s\"", text)
+				}
+			}
+			if i == 0 {
+				return Version{}, true, nil
+			}
+			v, err := Parse(strings.Join(segments[:i], "."))
+			return v, true, err
+		}
+	}
+
+	v, err := Parse(text)
+	return v, false, err
+}
+
+func (c Constraint) Check(v Version) bool {
+	for _, clauses := range c.alternatives {
+		if v.Prerelease != "" && !mentionsPrerelease(clauses) {
+			continue
+		}
+		if matchesAll(clauses, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func mentionsPrerelease(clauses []clause) bool {
+	for _, cl := range clauses {
+		if cl.version.Prerelease != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAll(clauses []clause, v Version) bool {
+	for _, cl := range clauses {
+		c := v.Compare(cl.version)
+		var ok bool
+		switch cl.op {
+		case "=":
+			ok = c == 0
+		case "!=":
+			ok = c != 0
+		case ">":
+			ok = c > 0
+		case ">=":
+			ok = c >= 0
+		case "<":
+			ok = c < 0
+		case "<=":
+			ok = c <= 0
+		case "!in":
+			ok = c < 0 || v.Compare(cl.upper) >= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Constraint) String() string {
+	return c.original
+}
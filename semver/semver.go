@@ -0,0 +1,151 @@
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a dotted numeric version with an optional pre-release and build
+// suffix. Pivotal Network versions don't always have exactly three segments
+// (stemcells use "3312.12"), so any number of numeric segments is accepted.
+type Version struct {
+	Segments   []int
+	Prerelease string
+	Metadata   string
+	original   string
+}
+
+func Parse(s string) (Version, error) {
+	original := s
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V")
+
+	v := Version{original: original}
+	if i := strings.Index(s, "+"); i >= 0 {
+		s, v.Metadata = s[:i], s[i+1:]
+	}
+	if i := strings.Index(s, "-"); i >= 0 {
+		s, v.Prerelease = s[:i], s[i+1:]
+	}
+
+	if s == "" {
+		return Version{}, fmt.Errorf("Invalid version \"%s\"", original)
+	}
+	for _, segment := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(segment)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("Invalid version \"%s\"", original)
+		}
+		v.Segments = append(v.Segments, n)
+	}
+	return v, nil
+}
+
+func MustParse(s string) Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (v Version) String() string {
+	if v.original != "" {
+		return v.original
+	}
+
+	segments := make([]string, len(v.Segments))
+	for i, n := range v.Segments {
+		segments[i] = strconv.Itoa(n)
+	}
+	s := strings.Join(segments, ".")
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Metadata != "" {
+		s += "+" + v.Metadata
+	}
+	return s
+}
+
+func (v Version) segment(i int) int {
+	if i < len(v.Segments) {
+		return v.Segments[i]
+	}
+	return 0
+}
+
+// Compare returns -1, 0 or 1 depending on whether v is lower than, equal to or
+// greater than other. Missing segments count as zero and build metadata is
+// ignored.
+func (v Version) Compare(other Version) int {
+	n := len(v.Segments)
+	if len(other.Segments) > n {
+		n = len(other.Segments)
+	}
+	for i := 0; i < n; i++ {
+		if c := compareInt(v.segment(i), other.segment(i)); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+	return comparePrerelease(v.Prerelease, other.Prerelease)
+}
+
+func (v Version) LessThan(other Version) bool {
+	return v.Compare(other) < 0
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func comparePrerelease(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		var c int
+		switch {
+		case aErr == nil && bErr == nil:
+			c = compareInt(aNum, bNum)
+		case aErr == nil:
+			c = -1
+		case bErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(aParts[i], bParts[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareInt(len(aParts), len(bParts))
+}
+
+// bump returns the version obtained by incrementing the segment at index i
+// and dropping everything after it, e.g. bump(1.8.3, 1) is 1.9.
+func (v Version) bump(i int) Version {
+	segments := make([]int, i+1)
+	for j := 0; j < i; j++ {
+		segments[j] = v.segment(j)
+	}
+	segments[i] = v.segment(i) + 1
+	return Version{Segments: segments}
+}
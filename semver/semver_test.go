@@ -0,0 +1,114 @@
+package semver_test
+
+import (
+	"github.com/cfmobile/gopivnet/semver"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Semver", func() {
+	Context("Parse", func() {
+		It("parses versions with any number of segments", func() {
+			v, err := semver.Parse("3312.12")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.Segments).To(Equal([]int{3312, 12}))
+
+			v, err = semver.Parse("v1.8.3-beta.2+build.5")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v.Segments).To(Equal([]int{1, 8, 3}))
+			Expect(v.Prerelease).To(Equal("beta.2"))
+			Expect(v.Metadata).To(Equal("build.5"))
+		})
+
+		It("returns an error for versions that aren't numeric", func() {
+			_, err := semver.Parse("1.8.latest")
+			Expect(err).To(HaveOccurred())
+
+			_, err = semver.Parse("")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Compare", func() {
+		It("orders versions numerically", func() {
+			Expect(semver.MustParse("1.10.0").Compare(semver.MustParse("1.9.3"))).To(Equal(1))
+			Expect(semver.MustParse("1.8").Compare(semver.MustParse("1.8.0"))).To(Equal(0))
+			Expect(semver.MustParse("1.8.0").Compare(semver.MustParse("1.8.1"))).To(Equal(-1))
+		})
+
+		It("orders pre-releases before the release", func() {
+			Expect(semver.MustParse("1.8.0-beta.2").LessThan(semver.MustParse("1.8.0"))).To(BeTrue())
+			Expect(semver.MustParse("1.8.0-beta.2").LessThan(semver.MustParse("1.8.0-beta.10"))).To(BeTrue())
+			Expect(semver.MustParse("1.8.0-alpha").LessThan(semver.MustParse("1.8.0-beta"))).To(BeTrue())
+		})
+	})
+
+	Context("Constraint", func() {
+		var matches = func(constraint string, versions ...string) []string {
+			c, err := semver.ParseConstraint(constraint)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+			matched := []string{}
+			for _, v := range versions {
+				if c.Check(semver.MustParse(v)) {
+					matched = append(matched, v)
+				}
+			}
+			return matched
+		}
+
+		versions := []string{"1.7.2", "1.7.3", "1.8.0", "1.8.12", "1.8.13-beta.1", "1.9.0", "2.0.0"}
+
+		It("matches exact versions", func() {
+			Expect(matches("1.8.12", versions...)).To(Equal([]string{"1.8.12"}))
+			Expect(matches("=1.8.12", versions...)).To(Equal([]string{"1.8.12"}))
+		})
+
+		It("treats partial versions as the whole line", func() {
+			Expect(matches("1.8", versions...)).To(Equal([]string{"1.8.0", "1.8.12"}))
+			Expect(matches("1", versions...)).To(Equal([]string{"1.7.2", "1.7.3", "1.8.0", "1.8.12", "1.9.0"}))
+		})
+
+		It("supports wildcards", func() {
+			Expect(matches("1.8.*", versions...)).To(Equal([]string{"1.8.0", "1.8.12"}))
+			Expect(matches("1.x", versions...)).To(Equal([]string{"1.7.2", "1.7.3", "1.8.0", "1.8.12", "1.9.0"}))
+			Expect(matches("*", versions...)).To(HaveLen(6))
+		})
+
+		It("supports the pessimistic operator", func() {
+			Expect(matches("~> 1.8", versions...)).To(Equal([]string{"1.8.0", "1.8.12", "1.9.0"}))
+			Expect(matches("~> 1.7.3", versions...)).To(Equal([]string{"1.7.3"}))
+		})
+
+		It("supports tilde and caret ranges", func() {
+			Expect(matches("~1.7.2", versions...)).To(Equal([]string{"1.7.2", "1.7.3"}))
+			Expect(matches("^1.8.0", versions...)).To(Equal([]string{"1.8.0", "1.8.12", "1.9.0"}))
+		})
+
+		It("combines comparisons", func() {
+			Expect(matches(">=1.7.3 <1.9", versions...)).To(Equal([]string{"1.7.3", "1.8.0", "1.8.12"}))
+			Expect(matches(">= 1.7.3, < 1.9, != 1.8.0", versions...)).To(Equal([]string{"1.7.3", "1.8.12"}))
+			Expect(matches("!= 1.8", versions...)).To(Equal([]string{"1.7.2", "1.7.3", "1.9.0", "2.0.0"}))
+		})
+
+		It("supports alternatives", func() {
+			Expect(matches("1.7.2 || >=2", versions...)).To(Equal([]string{"1.7.2", "2.0.0"}))
+		})
+
+		It("only matches pre-releases when asked for", func() {
+			Expect(matches(">=1.8.13-beta.0", versions...)).To(Equal([]string{"1.8.13-beta.1", "1.9.0", "2.0.0"}))
+		})
+
+		It("returns an error for invalid constraints", func() {
+			_, err := semver.ParseConstraint("~> 1.*")
+			Expect(err).To(HaveOccurred())
+
+			_, err = semver.ParseConstraint(">= banana")
+			Expect(err).To(HaveOccurred())
+
+			_, err = semver.ParseConstraint("")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
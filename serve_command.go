@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/cfmobile/gopivnet/mirror"
+)
+
+func serveCommand(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	dir := flags.String("dir", ".", "directory holding the files to serve, laid out as <product slug>/<version>/<file>")
+	listen := flags.String("listen", ":8080", "address to listen on")
+	tlsCert := flags.String("tls-cert", "", "PEM certificate to serve https with")
+	tlsKey := flags.String("tls-key", "", "PEM key of -tls-cert")
+	flags.Parse(args)
+
+	if (*tlsCert == "") != (*tlsKey == "") {
+		usageError("Need both -tls-cert and -tls-key")
+	}
+
+	server := &http.Server{
+		Addr:    *listen,
+		Handler: mirror.New(*dir),
+	}
+
+	log.Printf("Serving \"%s\" on %s", *dir, *listen)
+	var err error
+	if *tlsCert != "" {
+		err = server.ListenAndServeTLS(*tlsCert, *tlsKey)
+	} else {
+		err = server.ListenAndServe()
+	}
+	fatal(err)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/cfmobile/gopivnet/api"
+)
+
+type hopRow struct {
+	Step        int    `json:"step" yaml:"step"`
+	Id          int    `json:"id" yaml:"id"`
+	Version     string `json:"version" yaml:"version"`
+	ReleaseType string `json:"release_type" yaml:"release_type"`
+	ReleaseDate string `json:"release_date" yaml:"release_date"`
+}
+
+func planUpgradeCommand(args []string) {
+	cmd := newCommand("plan-upgrade")
+	product := cmd.flags.String("product", "", "product to upgrade")
+	from := cmd.flags.String("from", "", "installed version of the product")
+	to := cmd.flags.String("to", "", "version to upgrade to, or a constraint. Defaults to the latest version")
+	download := cmd.flags.Bool("download", false, "download the file of every release of the plan into -dir")
+	dir := cmd.flags.String("dir", ".", "directory where -download saves the files")
+	fileType := cmd.flags.String("fileType", "pivotal", "type of the files downloaded")
+	glob := cmd.flags.String("glob", "", "glob matched against the file names of each release, when more than one file has -fileType")
+	pivnetApi, ctx, cancel := cmd.parse(args)
+	defer cancel()
+
+	if *product == "" {
+		usageError("Need a product name")
+	}
+	if *from == "" {
+		usageError("Need the version to upgrade from")
+	}
+
+	plan, err := pivnetApi.PlanUpgradeContext(ctx, *product, *from, *to)
+	if err != nil {
+		fatal(err)
+	}
+
+	l := listing{header: []string{"STEP", "VERSION", "TYPE", "DATE"}}
+	data := []hopRow{}
+	for index, release := range plan {
+		data = append(data, hopRow{
+			Step:        index + 1,
+			Id:          release.Id,
+			Version:     release.Version,
+			ReleaseType: release.ReleaseType,
+			ReleaseDate: release.ReleaseDate,
+		})
+		l.rows = append(l.rows, []string{strconv.Itoa(index + 1), release.Version, release.ReleaseType, release.ReleaseDate})
+	}
+	l.data = data
+	cmd.print(l)
+
+	if !*download {
+		return
+	}
+
+	err = os.MkdirAll(*dir, 0755)
+	if err != nil {
+		fatal(err)
+	}
+
+	downloadOptions := api.DownloadOptions{Receipt: true, Warn: warn}
+	if jsonOutput {
+		reportDownloads(&downloadOptions, nil)
+	} else if *cmd.output == "table" {
+		printDownloads(&downloadOptions)
+		progressOptions(&downloadOptions, 0)
+	}
+	selector := api.FileSelector{FileType: *fileType, Glob: *glob}
+	for _, release := range plan {
+		productFile, err := pivnetApi.GetProductFileContext(ctx, *product, release.Version, selector)
+		if err != nil {
+			fatal(err)
+		}
+		err = pivnetApi.DownloadContext(ctx, productFile, filepath.Join(*dir, productFile.Name()), downloadOptions.ForRelease(*product, release))
+		if err != nil {
+			fatal(err)
+		}
+	}
+}
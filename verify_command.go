@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/cfmobile/gopivnet/api"
+)
+
+type verifyRow struct {
+	File      string `json:"file" yaml:"file"`
+	Product   string `json:"product,omitempty" yaml:"product,omitempty"`
+	ReleaseId int    `json:"release_id,omitempty" yaml:"release_id,omitempty"`
+	Version   string `json:"version,omitempty" yaml:"version,omitempty"`
+	FileId    int    `json:"file_id,omitempty" yaml:"file_id,omitempty"`
+	Sha256    string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+	Verified  bool   `json:"verified" yaml:"verified"`
+	Error     string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func verifyCommand(args []string) {
+	cmd := newCommand("verify")
+	offline := cmd.flags.Bool("offline", false, "only check the files against their receipts, without asking Pivotal Network")
+	cmd.parseFlags(args)
+
+	fileNames := cmd.flags.Args()
+	if len(fileNames) == 0 {
+		usageError("Need the files to verify")
+	}
+
+	verify := func(fileName string) (*api.Receipt, error) {
+		receipt, err := api.ReadReceipt(fileName)
+		if err != nil {
+			return nil, err
+		}
+		return receipt, receipt.Check(fileName)
+	}
+	if !*offline {
+		pivnetApi, ctx, cancel := cmd.newApi()
+		defer cancel()
+		verify = func(fileName string) (*api.Receipt, error) {
+			return pivnetApi.VerifyFileContext(ctx, fileName)
+		}
+	}
+
+	l := listing{header: []string{"FILE", "PRODUCT", "VERSION", "FILE ID", "STATUS"}}
+	data := []verifyRow{}
+	var failure error
+	for _, fileName := range fileNames {
+		receipt, err := verify(fileName)
+
+		row := verifyRow{File: fileName, Verified: err == nil}
+		if receipt != nil {
+			row.Product = receipt.Product
+			row.ReleaseId = receipt.ReleaseId
+			row.Version = receipt.ReleaseVersion
+			row.FileId = receipt.ProductFileId
+			row.Sha256 = receipt.Sha256
+		}
+		status := "ok"
+		if err != nil {
+			row.Error = err.Error()
+			status = err.Error()
+			if failure == nil {
+				failure = err
+			}
+		}
+
+		fileId := ""
+		if row.FileId != 0 {
+			fileId = strconv.Itoa(row.FileId)
+		}
+		data = append(data, row)
+		l.rows = append(l.rows, []string{fileName, row.Product, row.Version, fileId, status})
+	}
+	l.data = data
+	cmd.print(l)
+
+	if failure != nil {
+		fatal(failure)
+	}
+}